@@ -0,0 +1,645 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Regex patterns
+const (
+	chineseCharacterRegex = `[\p{Han}]`                                          // Matches individual Chinese characters
+	chineseWordsRegex     = `[\p{Han}]+`                                         // Matches sequences of Chinese characters as words
+	englishWordRegex      = `\b[a-zA-Z0-9']+(?:-[a-zA-Z0-9']+)?\b`               // Matches English words and compounds like "micro-video", also handle "I'll"
+	englishPhrasesRegex   = `\b[a-zA-Z0-9][\w\s'-]*[a-zA-Z0-9]\b`                // Matches English phrases with spaces
+	urlRegex              = `\b(?:[a-zA-Z][a-zA-Z0-9+.-]*://|www\.)[^\s<>"]+`    // Matches URLs like "https://example.com/a?b=c" and "www.example.com"
+	emailRegex            = `\b[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}\b` // Matches email addresses
+)
+
+// Compiled category patterns, replaced by the -*-regex overrides
+var (
+	chineseCharPattern   = regexp.MustCompile(chineseCharacterRegex)
+	chineseWordPattern   = regexp.MustCompile(chineseWordsRegex)
+	englishWordPattern   = regexp.MustCompile(englishWordRegex)
+	englishPhrasePattern = regexp.MustCompile(englishPhrasesRegex)
+)
+
+// Compiled URL and email patterns, matched on every line before the word regexes
+var (
+	urlPattern   = regexp.MustCompile(urlRegex)
+	emailPattern = regexp.MustCompile(emailRegex)
+)
+
+// Runs of digits collapsed by -normalize-digits
+var digitRunPattern = regexp.MustCompile(`\p{Nd}+`)
+
+// Replacement of an entity taken out of a line (a URL, email, hashtag, mention or Roman numeral).
+// "|" matches no word or phrase regex, so the entity ends the English phrase around it instead
+// of gluing the words on both sides into one phrase.
+const entityBreak = " | "
+
+// Runs of phrase separators merged into one space by -merge-whitespace-variants: anything but
+// letters, digits and apostrophes, so "state-of-the-art" and "state of  the art" count alike
+var phraseSeparatorPattern = regexp.MustCompile(`[^\p{L}\p{N}']+`)
+
+// Function to replace a category pattern with a user-supplied regex, keeping the default if expr
+// is empty. Patterns that can match the empty string are rejected, as they would count empty terms.
+func overridePattern(pattern **regexp.Regexp, expr string) error {
+	if expr == "" {
+		return nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	if re.MatchString("") {
+		return fmt.Errorf("%w: pattern %q matches the empty string", ErrInvalidOption, expr)
+	}
+	*pattern = re
+	return nil
+}
+
+// Token that runs of digits are replaced with by -normalize-digits
+const numPlaceholder = "<NUM>"
+
+// Punctuation trimmed from both ends of each token with -trim-punctuation
+const tokenPunctuation = `'"‘’“”-_.,;:!?()[]{}`
+
+// Line statistics of one analyzed input file
+type fileStats struct {
+	Path      string
+	Lines     int // Total lines read
+	FirstLine int // First and last tokenized line (0 when no line was in range)
+	LastLine  int
+	Skipped   int // Header lines skipped with -skip-lines
+	Malformed int // Lines without a valid weight, skipped with -weighted
+
+	// Index ranges [start, end) of this file's tokens in the duplicated word lists
+	EnglishWords [2]int
+	ChineseWords [2]int
+
+	LineStats []lineStat // Per-line statistics, collected with -per-line-stats
+}
+
+// Word statistics of one line
+type lineStat struct {
+	Line   int
+	Tokens int // English and Chinese words on the line
+	Unique int // Distinct words, English words compared case-insensitively
+}
+
+// Function to compute the statistics of a line from the words it produced
+func newLineStat(line int, englishWords, chineseWords []string) lineStat {
+	unique := make(map[string]struct{})
+	for _, word := range englishWords {
+		unique[strings.ToLower(word)] = struct{}{}
+	}
+	for _, word := range chineseWords {
+		unique[word] = struct{}{}
+	}
+	return lineStat{Line: line, Tokens: len(englishWords) + len(chineseWords), Unique: len(unique)}
+}
+
+// Frequency maps and duplicated lists accumulated over all input files
+type analysis struct {
+	// Frequency maps
+	chineseCharFreq    map[string]int
+	chineseWordsFreq   map[string]int
+	englishWordFreq    map[string]int
+	englishPhrasesFreq map[string]int
+	urlFreq            map[string]int
+	emailFreq          map[string]int
+	hashtagFreq        map[string]int
+	mentionFreq        map[string]int
+	lineFreq           map[string]int // Whole lines, counted with -line-frequency
+	mixedFreq          map[string]int // Tokens mixing Han and Latin letters, counted with -mixed-script
+	scriptFreq         map[string]int // Words of the -script script
+	romanFreq          map[string]int // Roman numerals, counted with -roman-numerals
+
+	kwicLines map[string][]lineRef // First lines of each Chinese character and English word, recorded with -kwic
+	lineIndex map[string][]lineRef // All their lines, recorded with -inverted-index
+	lineAt    lineRef              // Position of the line being analyzed
+
+	wordForms map[string]map[string]int // Spellings counted under each English word, recorded with -surface-forms
+
+	// Lists to retain duplications (as they appear in the original order)
+	chineseCharList    []string
+	chineseWordsList   []string
+	englishWordList    []string
+	englishPhrasesList []string
+	urlList            []string
+	emailList          []string
+	hashtagList        []string
+	mentionList        []string
+
+	englishKeyList  []string // Normalized keys counted for the English words, in order
+	englishKeyStart []int    // Index in englishKeyList of the first key of each englishWordList word
+
+	englishSentences int // Sentence ends found in English text, for readability scores
+	droppedTerms     int // Terms discarded for exceeding -max-term-length
+
+	files    []fileStats
+	sections []section // Sections started by -section-regex header lines
+
+	progress *progress // Progress of reading input files, nil unless -progress is set
+	flush    *flusher  // Periodic snapshot writer, nil unless -flush-interval is set
+
+	weight     int             // Count contributed by each term of the current line (-weighted)
+	drain      func() error    // Called after each line in -external mode to move its terms out
+	checkpoint *checkpointer   // Periodic checkpoint writer, nil unless -checkpoint-every is set
+	resumeFrom *checkpointData // Checkpoint of a partially scanned file to continue, set by -resume
+}
+
+// Function to create an empty analysis
+func newAnalysis() *analysis {
+	a := &analysis{
+		chineseCharFreq:    make(map[string]int),
+		chineseWordsFreq:   make(map[string]int),
+		englishWordFreq:    make(map[string]int),
+		englishPhrasesFreq: make(map[string]int),
+		urlFreq:            make(map[string]int),
+		emailFreq:          make(map[string]int),
+		hashtagFreq:        make(map[string]int),
+		mentionFreq:        make(map[string]int),
+		lineFreq:           make(map[string]int),
+		mixedFreq:          make(map[string]int),
+		scriptFreq:         make(map[string]int),
+		romanFreq:          make(map[string]int),
+		weight:             1,
+		chineseCharList:    []string{},
+		chineseWordsList:   []string{},
+		englishWordList:    []string{},
+		englishPhrasesList: []string{},
+		urlList:            []string{},
+		emailList:          []string{},
+		hashtagList:        []string{},
+		mentionList:        []string{},
+	}
+	if *kwicN > 0 {
+		a.kwicLines = make(map[string][]lineRef)
+	}
+	if *invertedIndex {
+		a.lineIndex = make(map[string][]lineRef)
+	}
+	if *surfaceSpelling {
+		a.wordForms = make(map[string]map[string]int)
+	}
+	return a
+}
+
+// Helper function to split a "text<TAB>weight" line of -weighted input; the weight must be a
+// positive integer after the last tab
+func parseWeightedLine(line string) (text string, weight int, ok bool) {
+	i := strings.LastIndexByte(line, '\t')
+	if i < 0 {
+		return "", 0, false
+	}
+	weight, err := strconv.Atoi(strings.TrimSpace(line[i+1:]))
+	if err != nil || weight < 1 {
+		return "", 0, false
+	}
+	return line[:i], weight, true
+}
+
+// Function to empty the frequency maps and lists, keeping the file statistics
+func (a *analysis) reset() {
+	for _, freqMap := range []map[string]int{a.chineseCharFreq, a.chineseWordsFreq, a.englishWordFreq,
+		a.englishPhrasesFreq, a.urlFreq, a.emailFreq, a.hashtagFreq, a.mentionFreq, a.lineFreq, a.mixedFreq, a.scriptFreq,
+		a.romanFreq} {
+		for term := range freqMap {
+			delete(freqMap, term)
+		}
+	}
+	for _, list := range []*[]string{&a.chineseCharList, &a.chineseWordsList, &a.englishWordList,
+		&a.englishPhrasesList, &a.urlList, &a.emailList, &a.hashtagList, &a.mentionList} {
+		*list = (*list)[:0]
+	}
+	a.englishKeyList, a.englishKeyStart = a.englishKeyList[:0], a.englishKeyStart[:0]
+	for _, refs := range []map[string][]lineRef{a.kwicLines, a.lineIndex} {
+		for term := range refs {
+			delete(refs, term)
+		}
+	}
+	for term := range a.wordForms {
+		delete(a.wordForms, term)
+	}
+}
+
+// Function to read an input file and add its terms to the analysis
+func (a *analysis) scanFile(path string) error {
+	// Open the input file
+	file, err := openRetrying(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Continue a file interrupted in a previous run where its checkpoint left off
+	stats, offset := a.newFileStats(path), int64(0)
+	if a.resumeFrom != nil && a.resumeFrom.Partial.Path == path {
+		stats, offset = a.resumeFrom.Partial, a.resumeFrom.Offset
+		a.resumeFrom = nil
+		if err := file.seek(offset); err != nil {
+			return err
+		}
+	}
+
+	var r io.Reader = file
+	if a.progress != nil {
+		a.progress.add(int(offset))
+		r = a.progress.reader(file)
+	}
+	return a.scanFrom(r, stats, offset)
+}
+
+// Function to read text from r and add its terms to the analysis; name identifies the input
+func (a *analysis) scan(name string, r io.Reader) error {
+	return a.scanFrom(r, a.newFileStats(name), 0)
+}
+
+// Helper function to start the statistics of an input at the current end of the word lists
+func (a *analysis) newFileStats(name string) fileStats {
+	stats := fileStats{Path: name}
+	stats.EnglishWords[0] = len(a.englishWordList)
+	stats.ChineseWords[0] = len(a.chineseWordsList)
+	return stats
+}
+
+// Function to scan an input from byte offset onwards, continuing its statistics
+func (a *analysis) scanFrom(r io.Reader, stats fileStats, offset int64) error {
+	// Reject UTF-16 input, which would otherwise be silently tokenized as garbage
+	reader := bufio.NewReader(r)
+	if bom, _ := reader.Peek(2); offset == 0 && len(bom) == 2 && (bom[0] == 0xFF && bom[1] == 0xFE || bom[0] == 0xFE && bom[1] == 0xFF) {
+		return fmt.Errorf("%w: UTF-16 byte order mark (convert the file to UTF-8)", ErrUnsupportedEncoding)
+	}
+
+	// Read the input line by line, tokenizing only lines within the selected range.
+	// The split function tracks the byte offset of the next line for checkpoints.
+	// With -chunk-size, long lines arrive as several chunks sharing their line number.
+	scanner := bufio.NewScanner(reader)
+	cut, continued := false, false
+	if *chunkSize > 0 && *chunkSize+2 > bufio.MaxScanTokenSize {
+		scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), *chunkSize+2) // Room for a chunk and its line end
+	}
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if *chunkSize > 0 {
+			advance, token, cut, err = splitChunks(data, atEOF, *chunkSize, *chunkOverlap)
+		} else {
+			advance, token, err = scanLines(data, atEOF)
+		}
+		offset += int64(advance)
+		return advance, token, err
+	})
+	var pipeline *linePipeline
+	if *ioThreads > 0 {
+		pipeline = newLinePipeline(a, *ioThreads, *ioBuffer)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !continued {
+			stats.Lines++
+		}
+		continued = cut
+		if stats.Lines <= *skipLines {
+			stats.Skipped++
+			continue
+		}
+		if stats.Lines < *startLine || (*endLine > 0 && stats.Lines > *endLine) {
+			continue
+		}
+		if stats.FirstLine == 0 {
+			stats.FirstLine = stats.Lines
+		}
+		stats.LastLine = stats.Lines
+
+		// Pre-aggregated input: every term of the text counts weight times
+		if *weighted {
+			text, weight, ok := parseWeightedLine(line)
+			if !ok {
+				stats.Malformed++
+				continue
+			}
+			line, a.weight = text, weight
+		}
+
+		// Hand the line to the -threads-io workers, or analyze it right away
+		if pipeline != nil {
+			pipeline.add(line, lineRef{Path: stats.Path, Line: stats.Lines}, a.weight)
+			continue
+		}
+		englishStart, chineseStart := len(a.englishWordList), len(a.chineseWordsList)
+		a.lineAt = lineRef{Path: stats.Path, Line: stats.Lines}
+		a.analyzeLine(line)
+		if a.flush != nil {
+			a.flush.tick()
+		}
+		if a.drain != nil {
+			if err := a.drain(); err != nil {
+				return err
+			}
+		}
+		if sectionPattern != nil && sectionPattern.MatchString(line) {
+			a.startSection(line, englishStart, chineseStart)
+		}
+		if *perLineStats {
+			stats.LineStats = append(stats.LineStats, newLineStat(stats.Lines,
+				a.englishWordList[englishStart:], a.chineseWordsList[chineseStart:]))
+		}
+		if a.checkpoint != nil && !continued {
+			a.checkpoint.tick(a, stats, offset)
+		}
+	}
+
+	// Wait for the lines still being analyzed, then handle scanner error
+	if pipeline != nil {
+		pipeline.finish()
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	stats.EnglishWords[1] = len(a.englishWordList)
+	stats.ChineseWords[1] = len(a.chineseWordsList)
+	a.files = append(a.files, stats)
+	return nil
+}
+
+// Function to tokenize one line and update the frequency maps and lists
+func (a *analysis) processLine(line string) {
+	// Remove invisible characters that split tokens, common in text copied from the web
+	if *stripInvisible {
+		line = invisibleReplacer.Replace(line)
+	}
+
+	// Undo ligatures and smart punctuation, e.g. of text extracted from PDFs
+	if *foldLigatures {
+		line = normalizeTypography(line)
+	}
+
+	// Merge look-alike Cyrillic and Greek letters into Latin ones
+	if *homoglyphMerge {
+		line = mergeHomoglyphs(line)
+	}
+
+	// Remove user-specified noise characters
+	if *stripChars != "" {
+		line = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(*stripChars, r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+
+	// Write Chinese numbers in Arabic digits, as the English words count them
+	if *chineseNumbers {
+		line = normalizeChineseNumbers(line)
+	}
+
+	// Count tokens mixing scripts before the category regexes split them
+	if *mixedScript {
+		a.countMixedScript(line)
+	}
+
+	// Match and process URLs and emails first, then replace them with entityBreak so the
+	// word regexes don't shred them into fragments
+	line = urlPattern.ReplaceAllStringFunc(line, func(match string) string {
+		url := trimURL(match)
+		a.urlFreq[url] += a.weight
+		a.urlList = append(a.urlList, url) // Append in original order
+		return entityBreak + match[len(url):]
+	})
+	line = emailPattern.ReplaceAllStringFunc(line, func(match string) string {
+		a.emailFreq[strings.ToLower(match)] += a.weight // Email addresses are case-insensitive in practice
+		a.emailList = append(a.emailList, match)
+		return entityBreak
+	})
+
+	// Match and process hashtags and mentions
+	if *social {
+		line = a.extractSocial(line)
+	}
+
+	// Match and process Roman numerals, keeping them out of the English words
+	if *romanNumerals {
+		line = a.extractRomanNumerals(line)
+	}
+
+	// Collapse each run of digits (including full-width ones) to a single "0", which processEnglish
+	// then turns into the placeholder; the regexes don't match "<" and ">" themselves
+	if *normalizeDigits {
+		line = digitRunPattern.ReplaceAllString(line, "0")
+	}
+
+	// Match and process the words of the user-selected script
+	if scriptPattern != nil {
+		for _, word := range scriptPattern.FindAllString(line, -1) {
+			if !a.tooLong(word) {
+				a.scriptFreq[strings.ToLower(word)] += a.weight
+			}
+		}
+	}
+
+	if runChinese {
+		a.processChinese(line)
+	}
+	if runEnglish {
+		a.processEnglish(line)
+	}
+}
+
+// Function to match and process the Chinese categories of a line
+func (a *analysis) processChinese(line string) {
+	// Match and process Chinese characters
+	chineseCharMatches := chineseCharPattern.FindAllString(line, -1)
+	for _, char := range chineseCharMatches {
+		a.chineseCharFreq[char] += a.weight
+		a.chineseCharList = append(a.chineseCharList, char) // Append in original order
+		a.recordLine(char)
+	}
+
+	// Match and process Chinese words
+	chineseWordMatches := chineseWordPattern.FindAllString(line, -1)
+	for _, word := range chineseWordMatches {
+		if a.tooLong(word) {
+			continue
+		}
+		a.chineseWordsFreq[word] += a.weight
+		a.chineseWordsList = append(a.chineseWordsList, word) // Append in original order
+	}
+}
+
+// Helper function to normalize an English word to the key it is counted under
+func englishKey(word string) string {
+	key := strings.ToLower(word) // Normalize to lowercase for consistency
+	if *diacriticFold {
+		key = foldDiacritics(key)
+	}
+	if *normalizeDigits {
+		key = strings.ReplaceAll(key, "0", numPlaceholder) // After lowercasing, which would change the placeholder
+	}
+	return key
+}
+
+// Helper function to return the normalized keys counted for the English words englishWordList[start:end]
+func (a *analysis) englishKeys(start, end int) []string {
+	keyAt := func(i int) int {
+		if i < len(a.englishKeyStart) {
+			return a.englishKeyStart[i]
+		}
+		return len(a.englishKeyList)
+	}
+	return a.englishKeyList[keyAt(start):keyAt(end)]
+}
+
+// Function to match and process the English categories of a line
+func (a *analysis) processEnglish(line string) {
+	a.englishSentences += len(sentenceEndPattern.FindAllStringIndex(line, -1))
+
+	// Optionally treat hyphens as word separators for English tokenization
+	englishLine := line
+	if *splitHyphens {
+		englishLine = strings.ReplaceAll(line, "-", " ")
+	}
+
+	// Match and process English words (with hyphenated compounds like "micro-video")
+	wordPattern := englishWordPattern
+	if *splitIdentifiers && *englishWordRe == "" {
+		wordPattern = identifierPattern
+	}
+	var englishWordMatches []string
+	if *tokenizer == "unicode" {
+		englishWordMatches = unicodeWords(englishLine)
+	} else {
+		englishWordMatches = wordPattern.FindAllString(englishLine, -1)
+	}
+	for _, word := range englishWordMatches {
+		if *trimPunctuation {
+			if word = strings.Trim(word, tokenPunctuation); word == "" {
+				continue
+			}
+		}
+		if a.tooLong(word) {
+			continue
+		}
+
+		// Count the words of identifiers, keeping the identifier itself in the duplicated list
+		parts := []string{word}
+		if *splitIdentifiers {
+			if parts = splitIdentifier(word); len(parts) == 0 {
+				continue
+			}
+		}
+		a.englishKeyStart = append(a.englishKeyStart, len(a.englishKeyList))
+		for _, part := range parts {
+			normalizedWord := englishKey(part)
+			a.englishWordFreq[normalizedWord] += a.weight
+			a.englishKeyList = append(a.englishKeyList, normalizedWord)
+			a.recordLine(normalizedWord)
+			if a.wordForms != nil {
+				surface := part
+				if *normalizeDigits {
+					surface = strings.ReplaceAll(surface, "0", numPlaceholder)
+				}
+				a.recordSurface(normalizedWord, surface)
+			}
+		}
+		if *normalizeDigits {
+			word = strings.ReplaceAll(word, "0", numPlaceholder)
+		}
+		a.englishWordList = append(a.englishWordList, word) // Append in original order
+	}
+
+	// Match and process English phrases
+	englishPhraseMatches := englishPhrasePattern.FindAllString(englishLine, -1)
+	for _, phrase := range englishPhraseMatches {
+		if *trimPunctuation {
+			if phrase = strings.Trim(phrase, tokenPunctuation); phrase == "" {
+				continue
+			}
+		}
+		if a.tooLong(phrase) {
+			continue
+		}
+		if *normalizeSpace {
+			phrase = strings.Join(strings.Fields(phrase), " ") // Collapse tabs and repeated spaces
+		}
+		normalizedPhrase := strings.ToLower(strings.TrimSpace(phrase)) // Normalize case and trim
+		if *mergeVariants {
+			normalizedPhrase = strings.TrimSpace(phraseSeparatorPattern.ReplaceAllString(normalizedPhrase, " "))
+		}
+		if *diacriticFold {
+			normalizedPhrase = foldDiacritics(normalizedPhrase)
+		}
+		if *normalizeDigits {
+			normalizedPhrase = strings.ReplaceAll(normalizedPhrase, "0", numPlaceholder) // After lowercasing, which would change the placeholder
+			phrase = strings.ReplaceAll(phrase, "0", numPlaceholder)
+		}
+		a.englishPhrasesFreq[normalizedPhrase] += a.weight
+		a.englishPhrasesList = append(a.englishPhrasesList, phrase) // Append in original order
+	}
+}
+
+// Function to analyze the text of a line at a.lineAt: count it as a whole with -line-frequency
+// (ignoring trailing whitespace and blank lines) and tokenize it, or its -region-regex regions
+func (a *analysis) analyzeLine(line string) {
+	if *lineFrequency {
+		if trimmed := strings.TrimRightFunc(line, unicode.IsSpace); trimmed != "" {
+			a.lineFreq[trimmed] += a.weight
+		}
+	}
+	if regionPattern != nil {
+		a.processRegions(line)
+	} else {
+		a.processLine(line)
+	}
+}
+
+// Helper function to check a term against -max-term-length, counting the terms it drops
+func (a *analysis) tooLong(term string) bool {
+	if *maxTermLength <= 0 || utf8.RuneCountInString(term) <= *maxTermLength {
+		return false
+	}
+	a.droppedTerms++
+	return true
+}
+
+// Function to count the whitespace-separated tokens of a line that contain both Han and
+// Latin letters, trimmed of surrounding punctuation
+func (a *analysis) countMixedScript(line string) {
+	for _, field := range strings.Fields(line) {
+		token := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if strings.IndexFunc(token, isHan) >= 0 && strings.IndexFunc(token, isLatin) >= 0 {
+			a.mixedFreq[token] += a.weight
+		}
+	}
+}
+
+// Helper functions to classify a rune's script
+func isHan(r rune) bool   { return unicode.Is(unicode.Han, r) }
+func isLatin(r rune) bool { return unicode.Is(unicode.Latin, r) }
+
+// Helper function to strip trailing punctuation that usually ends the sentence rather than the URL,
+// e.g. "https://example.com/a." -> "https://example.com/a". A closing parenthesis is kept when
+// it balances one inside the URL, as in "https://en.wikipedia.org/wiki/Go_(language)".
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'\"", last) >= 0:
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+		case last == ']' && strings.Count(url, "[") < strings.Count(url, "]"):
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+	return url
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Words the generated inputs are built from
+var (
+	benchEnglish = []string{"the", "quick", "brown", "fox", "jumps", "over", "lazy", "dog", "micro-video", "frequency"}
+	benchChinese = []string{"我们", "今天", "学习", "中文", "天气", "很好", "公园", "散步"}
+)
+
+// Helper function to generate an input of English lines
+func generateEnglish(lines int) string {
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "%s %s %s, %s and %s %d.\n",
+			benchEnglish[i%len(benchEnglish)], benchEnglish[(i*3)%len(benchEnglish)], benchEnglish[(i*7)%len(benchEnglish)],
+			benchEnglish[(i+1)%len(benchEnglish)], benchEnglish[(i*9)%len(benchEnglish)], i)
+	}
+	return b.String()
+}
+
+// Helper function to generate an input of Chinese lines
+func generateChinese(lines int) string {
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "%s%s，%s%s。\n",
+			benchChinese[i%len(benchChinese)], benchChinese[(i*5)%len(benchChinese)],
+			benchChinese[(i*3)%len(benchChinese)], benchChinese[(i+1)%len(benchChinese)])
+	}
+	return b.String()
+}
+
+// Helper function to generate an input of lines mixing English and Chinese
+func generateMixed(lines int) string {
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "%s %s %s, %s%s。 %s and %s %d.\n",
+			benchEnglish[i%len(benchEnglish)], benchEnglish[(i*3)%len(benchEnglish)], benchEnglish[(i*7)%len(benchEnglish)],
+			benchChinese[i%len(benchChinese)], benchChinese[(i*5)%len(benchChinese)],
+			benchEnglish[(i+1)%len(benchEnglish)], benchEnglish[(i*9)%len(benchEnglish)], i)
+	}
+	return b.String()
+}
+
+// Function to benchmark analyzing generated inputs of 10000 Chinese, English and mixed lines
+func BenchmarkAnalyze(b *testing.B) {
+	workloads := []struct {
+		name  string
+		input string
+	}{
+		{"chinese", generateChinese(10000)},
+		{"english", generateEnglish(10000)},
+		{"mixed", generateMixed(10000)},
+	}
+	for _, w := range workloads {
+		w := w
+		b.Run(w.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(w.input)))
+			for i := 0; i < b.N; i++ {
+				a := newAnalysis()
+				if err := a.scan("generated.txt", strings.NewReader(w.input)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Output file written under a temporary name and renamed into place on commit
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+// Function to create a temporary file next to path; the temporary file lives in the same
+// directory so the final rename stays on one filesystem
+func createAtomic(path string) (*atomicFile, error) {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+
+	// CreateTemp uses mode 0600; give outputs the usual permissions of a created file
+	if err := temp.Chmod(0644); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return nil, err
+	}
+	return &atomicFile{File: temp, path: path}, nil
+}
+
+// Function to close the temporary file and move it into place
+func (f *atomicFile) commit() error {
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	err := os.Rename(f.Name(), f.path)
+	if errors.Is(err, syscall.EXDEV) {
+		// Renames can't cross devices (e.g. a bind-mounted output file); fall back to
+		// copying, which is no longer atomic but still never truncates before the data is ready
+		err = copyFile(f.Name(), f.path)
+	}
+	os.Remove(f.Name()) // No-op once renamed
+	return err
+}
+
+// Function to discard the temporary file
+func (f *atomicFile) abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// Function to write data one item per line to path atomically
+func writeFileAtomic(path string, data []string) error {
+	file, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := writeLines(file, data); err != nil {
+		file.abort()
+		return err
+	}
+	return file.commit()
+}
+
+// Helper function to copy the contents of src over dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copying to %s: %v", dst, err)
+	}
+	return out.Close()
+}
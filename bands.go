@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Function to parse -band boundaries like "100,10" into distinct positive counts, highest first
+func parseBands(spec string) ([]int, error) {
+	var bounds []int
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(spec, ",") {
+		bound, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || bound < 2 {
+			return nil, fmt.Errorf("%w: band boundary %q must be an integer of at least 2", ErrInvalidOption, field)
+		}
+		if !seen[bound] {
+			seen[bound] = true
+			bounds = append(bounds, bound)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(bounds)))
+	return bounds, nil
+}
+
+// Helper function to name the band of counts in [low, high); high 0 means unbounded
+func bandName(low, high int) string {
+	if high == 0 {
+		return fmt.Sprintf("%dplus", low)
+	}
+	return fmt.Sprintf("%d-%d", low, high-1)
+}
+
+// Function to write a frequency-sorted category split into one file per band,
+// e.g. boundaries 100,10 give "_100plus", "_10-99" and "_1-9" files
+func writeBands(filePath string, sortedTerms []string, freqMap map[string]int, bounds []int) {
+	base := strings.TrimSuffix(filePath, ".txt")
+	high := 0
+	for i := 0; i <= len(bounds); i++ {
+		low := 1
+		if i < len(bounds) {
+			low = bounds[i]
+		}
+
+		var band []string
+		for _, term := range sortedTerms {
+			if count := freqMap[term]; count >= low && (high == 0 || count < high) {
+				band = append(band, term)
+			}
+		}
+		writeDeduplicated(base+"_"+bandName(low, high)+".txt", band, freqMap)
+		high = low
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Function to group frequency-sorted terms by their shared count, one "count<TAB>term term ..."
+// line per distinct count. sortByFrequency already orders counts descending and terms
+// within a count alphabetically.
+func formatByCount(sortedTerms []string, freqMap map[string]int) []string {
+	var lines []string
+	terms := outputTerms(sortedTerms)
+	for start := 0; start < len(sortedTerms); {
+		count := freqMap[sortedTerms[start]]
+		end := start
+		for end < len(sortedTerms) && freqMap[sortedTerms[end]] == count {
+			end++
+		}
+		lines = append(lines, fmt.Sprintf("%d\t%s", count, strings.Join(terms[start:end], " ")))
+		start = end
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+// Name and description of a token category or output format, listed by -list-categories and
+// -list-formats
+type catalogEntry struct {
+	name, description string
+}
+
+// Token categories counted by the analysis; the option counting an optional one is given in its
+// description
+var categoryCatalog = []catalogEntry{
+	{"chinese_characters", "individual Han characters"},
+	{"chinese_words", "runs of consecutive Han characters"},
+	{"english_words", "English words and hyphenated compounds, lower-cased"},
+	{"english_phrases", "runs of English words separated by spaces, hyphens or apostrophes, lower-cased"},
+	{"urls", "URLs like https://example.com/a or www.example.com"},
+	{"emails", "e-mail addresses"},
+	{"hashtags", "#hashtags (-social)"},
+	{"mentions", "@mentions (-social)"},
+	{"roman_numerals", "upper-case Roman numerals like XIV (-roman-numerals)"},
+	{"script", "words of another Unicode script, e.g. Cyrillic (-script)"},
+	{"mixed_script", "tokens mixing Han and Latin letters (-mixed-script)"},
+	{"lines", "identical whole lines (-line-frequency)"},
+}
+
+// Formats of the deduplicated outputs accepted by -format
+var formatCatalog = []catalogEntry{
+	{"txt", "one term per line, most frequent first (default)"},
+	{"parquet", "Parquet files with term and count columns, for Spark and pandas"},
+	{"go", "gofmt'ed Go source declaring a map[string]int per output"},
+	{"msgpack", "MessagePack arrays of {term, count} maps, like the -serve JSON results"},
+	{"graphml", "the -cooccurrence graph as GraphML for Gephi or Cytoscape; other outputs stay text"},
+}
+
+// Helper function to check whether name is listed in a catalog
+func inCatalog(catalog []catalogEntry, name string) bool {
+	for _, e := range catalog {
+		if e.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to print a catalog as aligned "name  description" lines
+func printCatalog(catalog []catalogEntry) {
+	width := 0
+	for _, e := range catalog {
+		if len(e.name) > width {
+			width = len(e.name)
+		}
+	}
+	for _, e := range catalog {
+		fmt.Printf("%-*s  %s\n", width, e.name, e.description)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Name of the checkpoint file in the output directory
+const checkpointFileName = ".checkpoint"
+
+// Serialized analysis state; mirrors the accumulated fields of analysis, exported for gob
+type checkpointState struct {
+	ChineseCharFreq, ChineseWordsFreq, EnglishWordFreq, EnglishPhrasesFreq map[string]int
+	URLFreq, EmailFreq, HashtagFreq, MentionFreq                           map[string]int
+	LineFreq, MixedFreq, ScriptFreq, RomanFreq                             map[string]int
+
+	ChineseCharList, ChineseWordsList, EnglishWordList, EnglishPhrasesList []string
+	URLList, EmailList, HashtagList, MentionList                           []string
+	EnglishKeyList                                                         []string
+	EnglishKeyStart                                                        []int
+
+	EnglishSentences int
+	DroppedTerms     int
+	Files            []fileStats // Completely scanned input files
+	Sections         []section
+	KwicLines        map[string][]lineRef
+	LineIndex        map[string][]lineRef
+	WordForms        map[string]map[string]int
+}
+
+// Contents of a checkpoint file
+type checkpointData struct {
+	Inputs []string // Input files and the SHA-256 of their contents when the checkpoint was taken
+	Hashes []string
+	State  checkpointState
+
+	Partial fileStats // Statistics of the input being scanned and the byte offset of its next line
+	Offset  int64
+}
+
+// Writer of a checkpoint every fixed number of analyzed lines
+type checkpointer struct {
+	path   string
+	every  int
+	count  int
+	inputs []string
+	hashes []string
+}
+
+// Function to create a checkpointer for the inputs, hashing their contents for later validation
+func newCheckpointer(path string, every int, inputs []string) (*checkpointer, error) {
+	hashes, err := hashInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	return &checkpointer{path: path, every: every, inputs: inputs, hashes: hashes}, nil
+}
+
+// Function to count an analyzed line and save a checkpoint when due
+func (c *checkpointer) tick(a *analysis, partial fileStats, offset int64) {
+	c.count++
+	if c.count%c.every != 0 {
+		return
+	}
+
+	data := checkpointData{Inputs: c.inputs, Hashes: c.hashes, State: a.exportState(), Partial: partial, Offset: offset}
+	if err := saveCheckpoint(c.path, &data); err != nil {
+		fmt.Printf("Error writing checkpoint %s: %v\n", c.path, err)
+	}
+}
+
+// Function to write a checkpoint atomically, so a crash while saving keeps the previous one
+func saveCheckpoint(path string, data *checkpointData) error {
+	file, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(file).Encode(data); err != nil {
+		file.abort()
+		return err
+	}
+	return file.commit()
+}
+
+// Function to read a checkpoint and check that it was taken for the same, unchanged inputs
+func loadCheckpoint(path string, inputs []string, hashes []string) (*checkpointData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var data checkpointData
+	if err := gob.NewDecoder(file).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decoding checkpoint: %v", err)
+	}
+
+	if len(data.Inputs) != len(inputs) {
+		return nil, fmt.Errorf("checkpoint was taken for %d input files, not %d", len(data.Inputs), len(inputs))
+	}
+	for i := range inputs {
+		if data.Inputs[i] != inputs[i] || data.Hashes[i] != hashes[i] {
+			return nil, fmt.Errorf("input %s does not match the checkpoint (changed or different file)", inputs[i])
+		}
+	}
+	return &data, nil
+}
+
+// Helper function to compute the SHA-256 of each input file
+func hashInputs(paths []string) ([]string, error) {
+	hashes := make([]string, len(paths))
+	for i, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
+
+// Function to copy the accumulated state of an analysis for a checkpoint
+func (a *analysis) exportState() checkpointState {
+	return checkpointState{
+		ChineseCharFreq: a.chineseCharFreq, ChineseWordsFreq: a.chineseWordsFreq,
+		EnglishWordFreq: a.englishWordFreq, EnglishPhrasesFreq: a.englishPhrasesFreq,
+		URLFreq: a.urlFreq, EmailFreq: a.emailFreq, HashtagFreq: a.hashtagFreq, MentionFreq: a.mentionFreq,
+		LineFreq: a.lineFreq, MixedFreq: a.mixedFreq, ScriptFreq: a.scriptFreq, RomanFreq: a.romanFreq,
+
+		ChineseCharList: a.chineseCharList, ChineseWordsList: a.chineseWordsList,
+		EnglishWordList: a.englishWordList, EnglishPhrasesList: a.englishPhrasesList,
+		URLList: a.urlList, EmailList: a.emailList, HashtagList: a.hashtagList, MentionList: a.mentionList,
+		EnglishKeyList: a.englishKeyList, EnglishKeyStart: a.englishKeyStart,
+
+		EnglishSentences: a.englishSentences,
+		DroppedTerms:     a.droppedTerms,
+		Files:            a.files,
+		Sections:         a.sections,
+		KwicLines:        a.kwicLines,
+		LineIndex:        a.lineIndex,
+		WordForms:        a.wordForms,
+	}
+}
+
+// Function to restore the accumulated state of an analysis from a checkpoint.
+// gob omits empty maps and slices, so missing ones are recreated empty.
+func (a *analysis) importState(s checkpointState) {
+	restoreMap := func(dst *map[string]int, src map[string]int) {
+		if src != nil {
+			*dst = src
+		}
+	}
+	restoreList := func(dst *[]string, src []string) {
+		if src != nil {
+			*dst = src
+		}
+	}
+
+	restoreMap(&a.chineseCharFreq, s.ChineseCharFreq)
+	restoreMap(&a.chineseWordsFreq, s.ChineseWordsFreq)
+	restoreMap(&a.englishWordFreq, s.EnglishWordFreq)
+	restoreMap(&a.englishPhrasesFreq, s.EnglishPhrasesFreq)
+	restoreMap(&a.urlFreq, s.URLFreq)
+	restoreMap(&a.emailFreq, s.EmailFreq)
+	restoreMap(&a.hashtagFreq, s.HashtagFreq)
+	restoreMap(&a.mentionFreq, s.MentionFreq)
+	restoreMap(&a.lineFreq, s.LineFreq)
+	restoreMap(&a.mixedFreq, s.MixedFreq)
+	restoreMap(&a.scriptFreq, s.ScriptFreq)
+	restoreMap(&a.romanFreq, s.RomanFreq)
+
+	restoreList(&a.chineseCharList, s.ChineseCharList)
+	restoreList(&a.chineseWordsList, s.ChineseWordsList)
+	restoreList(&a.englishWordList, s.EnglishWordList)
+	restoreList(&a.englishPhrasesList, s.EnglishPhrasesList)
+	restoreList(&a.urlList, s.URLList)
+	restoreList(&a.emailList, s.EmailList)
+	restoreList(&a.hashtagList, s.HashtagList)
+	restoreList(&a.mentionList, s.MentionList)
+	a.englishKeyList, a.englishKeyStart = s.EnglishKeyList, s.EnglishKeyStart
+
+	a.englishSentences = s.EnglishSentences
+	a.droppedTerms = s.DroppedTerms
+	a.files = s.Files
+	a.sections = s.Sections
+	if a.kwicLines != nil && s.KwicLines != nil {
+		a.kwicLines = s.KwicLines
+	}
+	if a.lineIndex != nil && s.LineIndex != nil {
+		a.lineIndex = s.LineIndex
+	}
+	if a.wordForms != nil && s.WordForms != nil {
+		a.wordForms = s.WordForms
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Matches runs of Chinese numeral characters, common and formal (financial) forms
+const chineseNumeralRegex = `[零〇一二两三四五六七八九十百千万亿壹贰叁肆伍陆柒捌玖拾佰仟]+`
+
+// Compiled chineseNumeralRegex
+var chineseNumeralPattern = regexp.MustCompile(chineseNumeralRegex)
+
+// Values of the Chinese digits and of the units multiplying them
+var (
+	chineseDigits = map[rune]int64{
+		'零': 0, '〇': 0, '一': 1, '壹': 1, '二': 2, '两': 2, '贰': 2, '三': 3, '叁': 3, '四': 4, '肆': 4,
+		'五': 5, '伍': 5, '六': 6, '陆': 6, '七': 7, '柒': 7, '八': 8, '捌': 8, '九': 9, '玖': 9,
+	}
+	chineseUnits = map[rune]int64{
+		'十': 10, '拾': 10, '百': 100, '佰': 100, '千': 1000, '仟': 1000, '万': 10000, '亿': 100000000,
+	}
+)
+
+// Function to replace the Chinese numbers of a line with Arabic digits (-normalize-chinese-numbers),
+// so "三" and "3" count as the same English word "3"
+func normalizeChineseNumbers(line string) string {
+	return chineseNumeralPattern.ReplaceAllStringFunc(line, func(numeral string) string {
+		if value, ok := parseChineseNumber(numeral); ok {
+			return value
+		}
+		return numeral
+	})
+}
+
+// Helper function to convert a run of Chinese numeral characters to Arabic digits. Runs without
+// units are read digit by digit ("二〇二四" is 2024); runs with units are summed positionally
+// ("三十五" is 35, "一百零五" 105, "十二" 12, "两万三千" 23000, "一亿三千万" 130000000).
+// Runs with larger values than 10^16 are left alone.
+func parseChineseNumber(numeral string) (string, bool) {
+	hasUnit := false
+	for _, r := range numeral {
+		if _, ok := chineseUnits[r]; ok {
+			hasUnit = true
+			break
+		}
+	}
+	if !hasUnit {
+		digits := make([]byte, 0, len(numeral)/3)
+		for _, r := range numeral {
+			digits = append(digits, byte('0'+chineseDigits[r]))
+		}
+		return string(digits), true
+	}
+
+	// total holds the part in units of 亿, wan the part in units of 万, section the value below
+	// 万 and digit the pending digit
+	var total, wan, section, digit int64
+	seenDigit := false
+	for _, r := range numeral {
+		if d, ok := chineseDigits[r]; ok {
+			digit, seenDigit = d, true
+			continue
+		}
+		// A unit without anything to multiply counts once, as in "十五" or "万一"
+		unit := chineseUnits[r]
+		if !seenDigit && (unit < 10000 || unit == 10000 && section == 0 || unit > 10000 && wan+section == 0) {
+			digit = 1
+		}
+		switch {
+		case unit == 100000000:
+			if total+wan+section+digit > 1e8 {
+				return "", false
+			}
+			total = (total + wan + section + digit) * unit
+			wan, section = 0, 0
+		case unit == 10000:
+			if wan+section+digit > 1e8 {
+				return "", false
+			}
+			wan = (wan + section + digit) * unit
+			section = 0
+		default:
+			section += digit * unit
+		}
+		digit, seenDigit = 0, false
+	}
+	return strconv.FormatInt(total+wan+section+digit, 10), true
+}
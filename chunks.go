@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Function to split input into lines ending with "\n", "\r\n" or a lone "\r" (old Mac line
+// endings, which bufio.ScanLines leaves inside the line), without the line ends
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		switch {
+		case data[i] == '\n':
+			return i + 1, data[:i], nil
+		case i+1 < len(data) && data[i+1] == '\n':
+			return i + 2, data[:i], nil
+		case i+1 < len(data) || atEOF:
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil // Request more data to tell "\r" from "\r\n"
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Function to split input into lines like scanLines, but cut lines longer than size bytes
+// into chunks (-chunk-size), so a huge line is never held whole. cut reports that token is a
+// chunk continued by the next token rather than the end of a line.
+func splitChunks(data []byte, atEOF bool, size, overlap int) (advance int, token []byte, cut bool, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 && i <= size || len(data) <= size && atEOF {
+		advance, token, err = scanLines(data, atEOF)
+		return advance, token, false, err
+	}
+	if len(data) <= size {
+		return 0, nil, false, nil // Request more data
+	}
+	n := chunkCut(data[:size], overlap)
+	return n, data[:n], true, nil
+}
+
+// Helper function to choose where to end a chunk, searching its last overlap bytes for the latest
+// place that doesn't split a term: preferably after punctuation or a symbol, which ends English
+// phrases and Chinese words, otherwise after whitespace, which only splits phrases. Without either
+// the chunk is cut at its last full character. The text after the cut starts the next chunk, so
+// terms in the overlap are counted once.
+func chunkCut(chunk []byte, overlap int) int {
+	space := 0
+	for end := len(chunk); end > 0 && end >= len(chunk)-overlap; {
+		r, n := utf8.DecodeLastRune(chunk[:end])
+		switch {
+		case r == utf8.RuneError:
+			// Part of a character cut off by the chunk size, or invalid UTF-8
+		case (unicode.IsPunct(r) || unicode.IsSymbol(r)) && r != '\'' && r != '-' && r != '_':
+			return end
+		case unicode.IsSpace(r) && space == 0:
+			space = end
+		}
+		end -= n
+	}
+	if space > 0 {
+		return space
+	}
+	end, start := len(chunk), len(chunk)-1
+	for start > 0 && !utf8.RuneStart(chunk[start]) && end-start < utf8.UTFMax {
+		start--
+	}
+	if !utf8.FullRune(chunk[start:]) && start > 0 {
+		end = start // Leave an incomplete character to the next chunk
+	}
+	return end
+}
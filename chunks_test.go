@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Helper function to split input into lines with scanLines
+func scanAll(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanLines)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+// Function to check that scanLines ends lines at "\n", "\r\n" and a lone "\r"
+func TestScanLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"lf", "one\ntwo\n", []string{"one", "two"}},
+		{"crlf", "one\r\ntwo\r\n", []string{"one", "two"}},
+		{"lone cr", "one\rtwo\r", []string{"one", "two"}},
+		{"mixed", "one\rtwo\r\nthree\nfour", []string{"one", "two", "three", "four"}},
+		{"no final line end", "one\ntwo", []string{"one", "two"}},
+		{"cr at end of input", "one\r", []string{"one"}},
+		{"empty lines", "\r\r\n\n", []string{"", "", ""}},
+		{"lf cr is two line ends", "one\n\rtwo", []string{"one", "", "two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scanAll(t, strings.NewReader(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanLines(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+
+			// Reading a byte at a time splits "\r\n" across reads
+			if got := scanAll(t, iotest.OneByteReader(strings.NewReader(tt.input))); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanLines(%q) read a byte at a time = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
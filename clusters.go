@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Group of near-duplicate terms represented by its most frequent member
+type termCluster struct {
+	Representative string
+	Members        []string // Most frequent first, including the representative
+	Count          int      // Combined count of all members
+}
+
+// Function to cluster terms within maxDistance edits of each other.
+// To bound the O(n²) comparisons, terms are only compared within blocks sharing the same
+// first character, and only when their lengths differ by at most maxDistance. Terms are
+// visited most frequent first, so each cluster is represented by its most frequent term.
+func clusterTerms(freqMap map[string]int, maxDistance int) []termCluster {
+	blocks := make(map[rune][]string)
+	var blockOrder []rune
+	for _, term := range sortByFrequency(freqMap) {
+		first, _ := utf8.DecodeRuneInString(term)
+		if _, ok := blocks[first]; !ok {
+			blockOrder = append(blockOrder, first)
+		}
+		blocks[first] = append(blocks[first], term)
+	}
+
+	var clusters []termCluster
+	for _, first := range blockOrder {
+		terms := blocks[first]
+		assigned := make([]bool, len(terms))
+		for i, rep := range terms {
+			if assigned[i] {
+				continue
+			}
+			cluster := termCluster{Representative: rep, Members: []string{rep}, Count: freqMap[rep]}
+			repLen := utf8.RuneCountInString(rep)
+			for j := i + 1; j < len(terms); j++ {
+				if assigned[j] {
+					continue
+				}
+				diff := utf8.RuneCountInString(terms[j]) - repLen
+				if diff < -maxDistance || diff > maxDistance {
+					continue
+				}
+				if levenshtein(rep, terms[j]) <= maxDistance {
+					assigned[j] = true
+					cluster.Members = append(cluster.Members, terms[j])
+					cluster.Count += freqMap[terms[j]]
+				}
+			}
+			if len(cluster.Members) > 1 {
+				clusters = append(clusters, cluster)
+			}
+		}
+	}
+	return clusters
+}
+
+// Helper function to compute the edit distance between two strings, in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Helper function to get the smallest of three ints
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// Function to format clusters as "representative combined_count member:count ..." lines
+func formatClusters(clusters []termCluster, freqMap map[string]int) []string {
+	var lines []string
+	for _, c := range clusters {
+		members := outputTerms(c.Members)
+		parts := make([]string, len(members))
+		for i, member := range members {
+			parts[i] = fmt.Sprintf("%s:%d", member, freqMap[c.Members[i]])
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %s", members[0], c.Count, strings.Join(parts, " ")))
+	}
+	return lines
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Helper function to get the display width of a string in terminal cells: East Asian wide
+// characters (Han, kana, Hangul, full-width forms) take two cells, combining marks none
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+		case isWide(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// Helper function to check whether a rune is displayed double-width
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK symbols and punctuation
+		(r >= 0xFF01 && r <= 0xFF60) || // Full-width forms
+		(r >= 0xFFE0 && r <= 0xFFE6)
+}
+
+// Helper function to pad a string with spaces to a display width
+func padRight(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// Function to lay out items in n aligned columns, filled column by column
+func formatColumns(items []string, n int) []string {
+	if n <= 1 || len(items) == 0 {
+		return items
+	}
+
+	rows := (len(items) + n - 1) / n
+	widths := make([]int, n)
+	for i, item := range items {
+		if w := displayWidth(item); w > widths[i/rows] {
+			widths[i/rows] = w
+		}
+	}
+
+	lines := make([]string, rows)
+	for row := range lines {
+		var cells []string
+		for col := 0; col < n; col++ {
+			i := col*rows + row
+			if i >= len(items) {
+				break
+			}
+			if col+1 < n && i+rows < len(items) {
+				cells = append(cells, padRight(items[i], widths[col]))
+			} else {
+				cells = append(cells, items[i])
+			}
+		}
+		lines[row] = strings.Join(cells, "  ")
+	}
+	return lines
+}
+
+// Function to print the n most frequent terms of a category with their counts to the console,
+// laid out in -columns columns
+func printTop(label string, freqMap map[string]int, n int) {
+	sorted := sortByFrequency(freqMap)
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	printTerms("Top "+label, sorted, freqMap)
+}
+
+// Function to print the n least frequent terms of a category, rarest first. The terms are
+// taken from the end of the frequency order; equal counts are listed alphabetically.
+func printTail(label string, freqMap map[string]int, n int) {
+	sorted := sortByFrequency(freqMap)
+	if len(sorted) > n {
+		sorted = sorted[len(sorted)-n:]
+	}
+
+	// Reverse the counts, keeping ties in alphabetical order
+	tail := make([]string, 0, len(sorted))
+	for end := len(sorted); end > 0; {
+		start := end - 1
+		for start > 0 && freqMap[sorted[start-1]] == freqMap[sorted[end-1]] {
+			start--
+		}
+		tail = append(tail, sorted[start:end]...)
+		end = start
+	}
+	printTerms("Least frequent "+label, tail, freqMap)
+}
+
+// Helper function to print terms with their counts under a heading, in -columns columns
+func printTerms(heading string, terms []string, freqMap map[string]int) {
+	items := make([]string, len(terms))
+	for i, term := range outputTerms(terms) {
+		items[i] = fmt.Sprintf("%s %d", term, freqMap[terms[i]])
+	}
+
+	fmt.Printf("%s:\n", heading)
+	for _, line := range formatColumns(items, *columns) {
+		fmt.Println("  " + line)
+	}
+}
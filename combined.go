@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Function to format the combined Chinese report: one "character count: word(count) ..." line
+// per character, most frequent first, listing the multi-character words containing it by
+// frequency. Chinese words are runs of Han characters, so they can be long for unsegmented text.
+func formatChineseCombined(a *analysis) []string {
+	// Index the words by the characters they contain
+	wordsByChar := make(map[string][]string)
+	for _, word := range sortByFrequency(a.chineseWordsFreq) {
+		if len([]rune(word)) < 2 {
+			continue // The character itself, already reported with its count
+		}
+		seen := make(map[rune]bool)
+		for _, r := range word {
+			if !seen[r] {
+				seen[r] = true
+				wordsByChar[string(r)] = append(wordsByChar[string(r)], word)
+			}
+		}
+	}
+
+	var lines []string
+	for _, char := range sortByFrequency(a.chineseCharFreq) {
+		words := wordsByChar[char]
+		terms := outputTerms(append([]string{char}, words...))
+		entries := make([]string, len(words))
+		for i, word := range words {
+			entries[i] = fmt.Sprintf("%s(%d)", terms[i+1], a.chineseWordsFreq[word])
+		}
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("%s %d: %s", terms[0], a.chineseCharFreq[char], strings.Join(entries, " "))))
+	}
+	return lines
+}
@@ -0,0 +1,40 @@
+package main
+
+import "fmt"
+
+// Function to check that the frequency maps and the duplicated lists agree (-validate): every
+// token appended to a category's duplicated list is counted once in its frequency map, so the
+// counts must sum to the list's length. Prints a pass or fail line per category and reports
+// whether all passed.
+func (a *analysis) checkCounts() bool {
+	categories := []struct {
+		name    string
+		freqMap map[string]int
+		list    []string
+	}{
+		{"chinese_characters", a.chineseCharFreq, a.chineseCharList},
+		{"chinese_words", a.chineseWordsFreq, a.chineseWordsList},
+		{"english_words", a.englishWordFreq, a.englishWordList},
+		{"english_phrases", a.englishPhrasesFreq, a.englishPhrasesList},
+		{"urls", a.urlFreq, a.urlList},
+		{"emails", a.emailFreq, a.emailList},
+		{"hashtags", a.hashtagFreq, a.hashtagList},
+		{"mentions", a.mentionFreq, a.mentionList},
+	}
+
+	passed := true
+	fmt.Println("Validation of counts against duplicated lists:")
+	for _, c := range categories {
+		sum := 0
+		for _, count := range c.freqMap {
+			sum += count
+		}
+		if sum == len(c.list) {
+			fmt.Printf("  %s: pass (%d tokens)\n", c.name, sum)
+			continue
+		}
+		fmt.Printf("  %s: FAIL (counts sum to %d, duplicated list has %d tokens)\n", c.name, sum, len(c.list))
+		passed = false
+	}
+	return passed
+}
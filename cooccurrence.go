@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of distinct pairs held in memory before pairs below the threshold are pruned.
+// Pruning trades exactness of low counts for bounded memory; when it frees less than half of
+// the room, the limit doubles so a map of frequent pairs isn't rescanned on every token.
+const cooccurrenceMaxPairs = 1 << 20
+
+// Function to count how often pairs of English words appear within window tokens of each other.
+// Pairs never span two input files, are order-independent and are keyed as "a b" with a < b.
+func countCooccurrences(a *analysis, window, minCount int) map[string]int {
+	pairFreq := make(map[string]int)
+	maxPairs := cooccurrenceMaxPairs
+	for _, f := range a.files {
+		words := a.englishKeys(f.EnglishWords[0], f.EnglishWords[1])
+		for i := range words {
+			for j := i + 1; j < len(words) && j-i <= window; j++ {
+				x, y := words[i], words[j]
+				if x == y {
+					continue
+				}
+				if x > y {
+					x, y = y, x
+				}
+				pairFreq[x+" "+y]++
+			}
+
+			if len(pairFreq) > maxPairs {
+				prunePairs(pairFreq, minCount)
+				if len(pairFreq) > maxPairs/2 {
+					maxPairs *= 2
+				}
+			}
+		}
+	}
+
+	prunePairs(pairFreq, minCount)
+	return pairFreq
+}
+
+// Helper function to drop pairs whose count is below minCount
+func prunePairs(pairFreq map[string]int, minCount int) {
+	for pair, count := range pairFreq {
+		if count < minCount {
+			delete(pairFreq, pair)
+		}
+	}
+}
+
+// Function to format co-occurrence pairs as "word1 word2 count" lines, most frequent first
+func formatCooccurrences(pairFreq map[string]int) []string {
+	var lines []string
+	for _, pair := range sortByFrequency(pairFreq) {
+		words := outputTerms(strings.SplitN(pair, " ", 2))
+		lines = append(lines, fmt.Sprintf("%s %s %d", words[0], words[1], pairFreq[pair]))
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// Function to load a wordlist (one word per line; blank lines and lines starting with "#"
+// are ignored) in file order
+func loadWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readWordList(file)
+}
+
+// Helper function to read a wordlist, skipping blank lines and "#" comments
+func readWordList(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words, scanner.Err()
+}
+
+// Function to load a wordlist into a set. Words are lowercased to match the case-folded English counts.
+func loadWordSet(path string) (map[string]struct{}, error) {
+	list, err := loadWordList(path)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make(map[string]struct{}, len(list))
+	for _, word := range list {
+		words[strings.ToLower(word)] = struct{}{}
+	}
+	return words, nil
+}
+
+// Helper function to keep only the terms present in the dictionary, preserving order
+func filterDictionary(terms []string, dictionary map[string]struct{}) []string {
+	var kept []string
+	for _, term := range terms {
+		if _, ok := dictionary[strings.ToLower(term)]; ok {
+			kept = append(kept, term)
+		}
+	}
+	return kept
+}
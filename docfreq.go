@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Function to format the document frequency of the English (lower-cased) and Chinese words as
+// "term total_count document_count" lines, where document_count is the number of input files the
+// term occurs in. Most widespread terms first, ties by total count, then alphabetically.
+func formatDocumentFrequency(a *analysis) []string {
+	total := make(map[string]int)
+	inDocs := make(map[string]int)
+	for _, counts := range documentCounts(a) {
+		for term, count := range counts {
+			total[term] += count
+			inDocs[term]++
+		}
+	}
+
+	terms := make([]string, 0, len(total))
+	for term := range total {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if inDocs[terms[i]] != inDocs[terms[j]] {
+			return inDocs[terms[i]] > inDocs[terms[j]]
+		}
+		if total[terms[i]] != total[terms[j]] {
+			return total[terms[i]] > total[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	lines := make([]string, len(terms))
+	for i, term := range outputTerms(terms) {
+		lines[i] = fmt.Sprintf("%s %d %d", term, total[terms[i]], inDocs[terms[i]])
+	}
+	return lines
+}
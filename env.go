@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Prefix of environment variables that provide defaults for command-line options
+const envPrefix = "TXTFREQ_"
+
+// Helper function to derive the environment variable name of a flag, e.g. "cooccurrence-min" -> "TXTFREQ_COOCCURRENCE_MIN"
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Function to fill flags not given on the command line from TXTFREQ_* environment variables.
+// Precedence: command-line flag > environment variable > built-in default.
+func applyEnv(fs *flag.FlagSet) error {
+	setOnCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || setOnCommandLine[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%w: value %q for %s: %v", ErrInvalidOption, value, envName(f.Name), setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,22 @@
+package main
+
+import "errors"
+
+// Errors reported by the analysis; returned errors wrap them with %w,
+// so callers can test for them with errors.Is
+var (
+	// ErrNoInput means an input pattern matched no files
+	ErrNoInput = errors.New("no input files")
+
+	// ErrEmptyInput means the input contains no text at all
+	ErrEmptyInput = errors.New("empty input")
+
+	// ErrUnsupportedEncoding means the input is not UTF-8 text (e.g. UTF-16 with a byte order mark)
+	ErrUnsupportedEncoding = errors.New("unsupported encoding")
+
+	// ErrInvalidOption means an option value could not be parsed
+	ErrInvalidOption = errors.New("invalid option")
+
+	// ErrInvalidResults means a JSON results file read by -merge-json is not shaped like the results
+	ErrInvalidResults = errors.New("invalid JSON results")
+)
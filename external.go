@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"container/heap"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Term and count of an external-memory run
+type runEntry struct {
+	Term  string
+	Count int
+}
+
+// Helper functions ordering run entries by term, and by descending count with ties by term
+// (the order of sortByFrequency)
+func lessTerm(x, y runEntry) bool { return x.Term < y.Term }
+func lessFreq(x, y runEntry) bool {
+	if x.Count != y.Count {
+		return x.Count > y.Count
+	}
+	return x.Term < y.Term
+}
+
+// Counter of one category holding at most max distinct terms in memory; beyond that the
+// counts are spilled to sorted run files on disk and merged at the end (-external)
+type externalCounter struct {
+	dir   string
+	max   int
+	freq  map[string]int
+	runs  []string // Run files sorted by term
+	total int      // Tokens counted
+}
+
+// Function to create an external counter spilling into dir
+func newExternalCounter(dir string, max int) *externalCounter {
+	return &externalCounter{dir: dir, max: max, freq: make(map[string]int)}
+}
+
+// Function to count a term, spilling the in-memory counts once max distinct terms are held
+func (c *externalCounter) add(term string) error {
+	c.freq[term]++
+	c.total++
+	if len(c.freq) >= c.max {
+		return c.spill()
+	}
+	return nil
+}
+
+// Function to write the in-memory counts to a new run file, sorted by term
+func (c *externalCounter) spill() error {
+	entries := make([]runEntry, 0, len(c.freq))
+	for term, count := range c.freq {
+		entries = append(entries, runEntry{term, count})
+	}
+	path, err := writeRun(c.dir, entries, lessTerm)
+	if err != nil {
+		return err
+	}
+	c.runs = append(c.runs, path)
+	c.freq = make(map[string]int)
+	return nil
+}
+
+// Function to write the merged counts to filePath in frequency order, one term per line.
+// Counts of equal terms are summed while merging the runs by term; the summed entries are
+// then sorted by frequency in runs of at most max entries and merged again.
+func (c *externalCounter) writeSorted(filePath string) (distinct int, err error) {
+	sources := []runIterator{newSliceIterator(c.freq, lessTerm)}
+	for _, path := range c.runs {
+		it, err := openRun(path)
+		if err != nil {
+			return 0, err
+		}
+		defer it.close()
+		sources = append(sources, it)
+	}
+
+	// Sum the counts per term, re-sorting by frequency in bounded chunks
+	var chunk []runEntry
+	var byFreq []string
+	flush := func() error {
+		path, err := writeRun(c.dir, chunk, lessFreq)
+		byFreq = append(byFreq, path)
+		chunk = chunk[:0]
+		return err
+	}
+	var current runEntry
+	err = mergeRuns(sources, lessTerm, func(e runEntry) error {
+		if e.Term == current.Term && current.Count > 0 {
+			current.Count += e.Count
+			return nil
+		}
+		if current.Count > 0 {
+			distinct++
+			if chunk = append(chunk, current); len(chunk) >= c.max {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		current = e
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if current.Count > 0 {
+		distinct++
+		chunk = append(chunk, current)
+	}
+
+	// The last chunk is merged from memory
+	sort.Slice(chunk, func(i, j int) bool { return lessFreq(chunk[i], chunk[j]) })
+	final := []runIterator{&sliceIterator{entries: chunk}}
+	for _, path := range byFreq {
+		it, err := openRun(path)
+		if err != nil {
+			return 0, err
+		}
+		defer it.close()
+		final = append(final, it)
+	}
+
+	out, err := createStream(filePath)
+	if err != nil {
+		return 0, err
+	}
+	err = mergeRuns(final, lessFreq, func(e runEntry) error {
+		return out.writeLine(outputTerms([]string{e.Term})[0])
+	})
+	if err != nil {
+		out.abort()
+		return 0, err
+	}
+	return distinct, out.commit()
+}
+
+// Function to write entries sorted by less to a new "count<TAB>term" run file in dir
+func writeRun(dir string, entries []runEntry, less func(x, y runEntry) bool) (string, error) {
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+
+	file, err := os.CreateTemp(dir, "run*")
+	if err != nil {
+		return "", err
+	}
+	writer := bufio.NewWriter(file)
+	for _, e := range entries {
+		writer.WriteString(strconv.Itoa(e.Count) + "\t" + e.Term + "\n")
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// Sorted source of run entries
+type runIterator interface {
+	next() (runEntry, bool, error)
+}
+
+// Run entries held in memory
+type sliceIterator struct {
+	entries []runEntry
+}
+
+// Function to iterate over the counts of freq, sorted by less
+func newSliceIterator(freq map[string]int, less func(x, y runEntry) bool) *sliceIterator {
+	entries := make([]runEntry, 0, len(freq))
+	for term, count := range freq {
+		entries = append(entries, runEntry{term, count})
+	}
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	return &sliceIterator{entries: entries}
+}
+
+func (it *sliceIterator) next() (runEntry, bool, error) {
+	if len(it.entries) == 0 {
+		return runEntry{}, false, nil
+	}
+	e := it.entries[0]
+	it.entries = it.entries[1:]
+	return e, true, nil
+}
+
+// Run entries read back from a run file
+type fileIterator struct {
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+// Function to open a run file for reading
+func openRun(path string) (*fileIterator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30) // Terms are bounded by -max-term-length, lines by the input
+	return &fileIterator{file: file, scanner: scanner}, nil
+}
+
+func (it *fileIterator) next() (runEntry, bool, error) {
+	if !it.scanner.Scan() {
+		return runEntry{}, false, it.scanner.Err()
+	}
+	count, term, _ := strings.Cut(it.scanner.Text(), "\t")
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return runEntry{}, false, fmt.Errorf("corrupt run file %s: %v", it.file.Name(), err)
+	}
+	return runEntry{term, n}, true, nil
+}
+
+func (it *fileIterator) close() {
+	it.file.Close()
+}
+
+// Heap of the current entries of the merged sources
+type mergeHeap struct {
+	heads   []runEntry
+	sources []runIterator
+	less    func(x, y runEntry) bool
+}
+
+func (h *mergeHeap) Len() int           { return len(h.heads) }
+func (h *mergeHeap) Less(i, j int) bool { return h.less(h.heads[i], h.heads[j]) }
+func (h *mergeHeap) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+	h.sources[i], h.sources[j] = h.sources[j], h.sources[i]
+}
+func (h *mergeHeap) Push(x interface{}) {}
+func (h *mergeHeap) Pop() interface{} {
+	h.heads = h.heads[:len(h.heads)-1]
+	h.sources = h.sources[:len(h.sources)-1]
+	return nil
+}
+
+// Function to merge sorted sources, calling emit for every entry in less order
+func mergeRuns(sources []runIterator, less func(x, y runEntry) bool, emit func(runEntry) error) error {
+	h := &mergeHeap{less: less}
+	for _, source := range sources {
+		e, ok, err := source.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.heads = append(h.heads, e)
+			h.sources = append(h.sources, source)
+		}
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		if err := emit(h.heads[0]); err != nil {
+			return err
+		}
+		e, ok, err := h.sources[0].next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.heads[0] = e
+			heap.Fix(h, 0)
+		} else {
+			heap.Remove(h, 0)
+		}
+	}
+	return nil
+}
+
+// Output file written line by line, gzip-compressed when -compress is set, and
+// moved into place atomically on commit
+type streamFile struct {
+	file   *atomicFile
+	gz     *gzip.Writer
+	writer *bufio.Writer
+	path   string
+}
+
+// Function to create a streamed output file (adding the ".gz" suffix with -compress)
+func createStream(filePath string) (*streamFile, error) {
+	if *compressOutput {
+		filePath += ".gz"
+	}
+	file, err := createAtomic(filePath)
+	if err != nil {
+		return nil, err
+	}
+	s := &streamFile{file: file, path: filePath}
+	if *compressOutput {
+		s.gz = gzip.NewWriter(file)
+		s.writer = bufio.NewWriter(s.gz)
+	} else {
+		s.writer = bufio.NewWriter(file)
+	}
+	return s, nil
+}
+
+func (s *streamFile) writeLine(line string) error {
+	_, err := s.writer.WriteString(line + "\n")
+	return err
+}
+
+func (s *streamFile) commit() error {
+	if err := s.writer.Flush(); err != nil {
+		s.file.abort()
+		return err
+	}
+	if s.gz != nil {
+		if err := s.gz.Close(); err != nil {
+			s.file.abort()
+			return err
+		}
+	}
+	return s.file.commit()
+}
+
+func (s *streamFile) abort() {
+	s.file.abort()
+}
+
+// Streams of one category in -external mode: the duplicated list is written as it is
+// scanned and the deduplicated counts kept by an external counter
+type externalCategory struct {
+	name     string
+	dedup    string
+	dup      *streamFile
+	counter  *externalCounter
+	distinct int
+}
+
+// Function to analyze the inputs with bounded memory (-external): only the deduplicated and
+// duplicated Chinese character and English word outputs are produced. Instead of keeping every
+// term in memory, each line's terms are streamed to the duplicated outputs and counted by
+// external counters holding at most maxTerms distinct terms each.
+func runExternal(inputFiles []string, maxTerms int, chineseDedup, chineseDup, englishDedup, englishDup string) error {
+	dir, err := os.MkdirTemp(*outDir, ".txtfreq-external*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var categories []*externalCategory
+	open := func(name, dedup, dup string) (*externalCategory, error) {
+		stream, err := createStream(dup)
+		if err != nil {
+			return nil, err
+		}
+		c := &externalCategory{name: name, dedup: dedup, dup: stream, counter: newExternalCounter(dir, maxTerms)}
+		categories = append(categories, c)
+		return c, nil
+	}
+	defer func() {
+		for _, c := range categories {
+			if c.dup != nil {
+				c.dup.abort()
+			}
+		}
+	}()
+
+	var chinese, english *externalCategory
+	if runChinese {
+		if chinese, err = open("Chinese characters", chineseDedup, chineseDup); err != nil {
+			return err
+		}
+	}
+	if runEnglish {
+		if english, err = open("English words", englishDedup, englishDup); err != nil {
+			return err
+		}
+	}
+
+	// Drain each line's terms out of the analysis, so it never holds more than one line
+	a := newAnalysis()
+	a.drain = func() error {
+		if chinese != nil {
+			for _, char := range a.chineseCharList {
+				if err := chinese.add(char, char); err != nil {
+					return err
+				}
+			}
+		}
+		if english != nil {
+			for i, word := range duplicatedEnglish(a.englishWordList) {
+				if err := english.add(word, a.englishKeys(i, i+1)...); err != nil {
+					return err
+				}
+			}
+		}
+		a.reset()
+		return nil
+	}
+	for _, path := range inputFiles {
+		if err := a.scanFile(path); err != nil {
+			return fmt.Errorf("reading input file %s: %w", path, err)
+		}
+	}
+
+	for _, c := range categories {
+		if err := c.dup.commit(); err != nil {
+			return err
+		}
+		c.dup = nil
+		if c.distinct, err = c.counter.writeSorted(c.dedup); err != nil {
+			return err
+		}
+	}
+
+	// Report how much the bounded counters saved over holding every distinct term
+	fmt.Println("All output files written successfully.")
+	fmt.Println()
+	fmt.Println("Summary (external mode):")
+	for _, c := range categories {
+		held := c.distinct
+		if held > maxTerms {
+			held = maxTerms
+		}
+		fmt.Printf("%s: %d tokens, %d distinct; held at most %d in memory (%d run files spilled)\n",
+			c.name, c.counter.total, c.distinct, held, len(c.counter.runs))
+	}
+	return nil
+}
+
+// Function to stream a term to the duplicated output and count its normalized keys
+func (c *externalCategory) add(term string, keys ...string) error {
+	if err := c.dup.writeLine(outputTerms([]string{term})[0]); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := c.counter.add(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Function to pipe tokens through an external filter command and read back the transformed tokens.
+// Protocol: the command gets one token per line on stdin and must print exactly one line per
+// input line on stdout, in order; an empty output line drops the token. The command line is
+// split on whitespace and run directly, without a shell.
+func runFilterCommand(command string, tokens []string) ([]string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: empty filter command", ErrInvalidOption)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// Feed the tokens concurrently so a command writing while reading can't deadlock
+	go func() {
+		writer := bufio.NewWriter(stdin)
+		for _, token := range tokens {
+			writer.WriteString(token + "\n")
+		}
+		writer.Flush()
+		stdin.Close()
+	}()
+
+	var filtered []string
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		filtered = append(filtered, scanner.Text())
+	}
+	scanErr := scanner.Err()
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("filter command %q: %v", command, err)
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	if len(filtered) != len(tokens) {
+		return nil, fmt.Errorf("filter command %q returned %d lines for %d tokens", command, len(filtered), len(tokens))
+	}
+	return filtered, nil
+}
+
+// Function to transform the English and Chinese words of an analysis with the filter command
+// and recount their frequencies. Per-file and per-section token ranges are remapped for dropped tokens.
+func (a *analysis) applyFilterCommand(command string) error {
+	english, err := runFilterCommand(command, a.englishWordList)
+	if err != nil {
+		return err
+	}
+	chinese, err := runFilterCommand(command, a.chineseWordsList)
+	if err != nil {
+		return err
+	}
+
+	var englishIndex, chineseIndex []int
+	a.englishWordList, _, englishIndex = recount(english)
+	a.chineseWordsList, a.chineseWordsFreq, chineseIndex = recount(chinese)
+	a.recountEnglish()
+	for i := range a.files {
+		f := &a.files[i]
+		f.EnglishWords = [2]int{englishIndex[f.EnglishWords[0]], englishIndex[f.EnglishWords[1]]}
+		f.ChineseWords = [2]int{chineseIndex[f.ChineseWords[0]], chineseIndex[f.ChineseWords[1]]}
+	}
+	for i := range a.sections {
+		s := &a.sections[i]
+		s.EnglishHeader, s.EnglishStart = englishIndex[s.EnglishHeader], englishIndex[s.EnglishStart]
+		s.ChineseHeader, s.ChineseStart = chineseIndex[s.ChineseHeader], chineseIndex[s.ChineseStart]
+	}
+	return nil
+}
+
+// Helper function to rebuild the English keys and their frequencies from the filtered duplicated list
+func (a *analysis) recountEnglish() {
+	a.englishWordFreq = make(map[string]int)
+	a.englishKeyList, a.englishKeyStart = []string{}, []int{}
+	for _, word := range a.englishWordList {
+		if *normalizeDigits {
+			word = strings.ReplaceAll(word, numPlaceholder, "0") // englishKey puts the placeholder back
+		}
+		parts := []string{word}
+		if *splitIdentifiers {
+			parts = splitIdentifier(word) // As processEnglish counts them
+		}
+		a.englishKeyStart = append(a.englishKeyStart, len(a.englishKeyList))
+		for _, part := range parts {
+			key := englishKey(part)
+			a.englishKeyList = append(a.englishKeyList, key)
+			a.englishWordFreq[key]++
+		}
+	}
+}
+
+// Helper function to rebuild a list and frequency map from filtered tokens, dropping empty ones.
+// index maps each old list position (and the end) to its position in the new list.
+func recount(tokens []string) (list []string, freqMap map[string]int, index []int) {
+	list = []string{}
+	freqMap = make(map[string]int)
+	index = make([]int, len(tokens)+1)
+	for i, token := range tokens {
+		index[i] = len(list)
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		list = append(list, token)
+		freqMap[token]++
+	}
+	index[len(tokens)] = len(list)
+	return list, freqMap, index
+}
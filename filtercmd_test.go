@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Function to check that -filter-cmd dropping tokens keeps the -section-regex sections aligned
+func TestFilterCommandSections(t *testing.T) {
+	defer func(pattern *regexp.Regexp) { sectionPattern = pattern }(sectionPattern)
+	sectionPattern = regexp.MustCompile(`^\d{4}-`)
+
+	input := "2024-01 first\nfoo bar 中中\n2024-02 second\nfoo baz 文字\n"
+	a := newAnalysis()
+	if err := a.scan("sections.txt", strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.applyFilterCommand("sed -e s/^foo$// -e s/^中中$//"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"term\t2024-01 first\t2024-02 second",
+		"bar\t1\t0",
+		"baz\t0\t1",
+		"文字\t0\t1",
+	}
+	if got := formatSectionMatrix(a); !reflect.DeepEqual(got, want) {
+		t.Errorf("formatSectionMatrix = %q, want %q", got, want)
+	}
+}
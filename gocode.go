@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Function to write a deduplicated output as a Go source file declaring a map literal,
+// for embedding frequency tables into other Go programs
+func writeGoSource(filePath string, sortedTerms []string, freqMap map[string]int) {
+	var buf bytes.Buffer
+	buf.WriteString(goSourceHeader)
+	writeGoMap(&buf, goIdentifier(filePath), sortedTerms, freqMap)
+
+	// gofmt the source so it can be committed as is
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Printf("Error formatting Go source %s: %v\n", filePath, err)
+		return
+	}
+
+	file, err := createAtomic(filePath)
+	if err != nil {
+		fmt.Printf("Error creating file %s: %v\n", filePath, err)
+		return
+	}
+	if _, err := file.Write(source); err != nil {
+		file.abort()
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+		return
+	}
+	if err := file.commit(); err != nil {
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+	}
+}
+
+// Header of the generated Go source files
+const goSourceHeader = "// Code generated by txt-frequency; DO NOT EDIT.\n\npackage frequencies\n\n"
+
+// Helper function to write the declaration of a map literal variable holding the counts
+func writeGoMap(buf *bytes.Buffer, name string, sortedTerms []string, freqMap map[string]int) {
+	fmt.Fprintf(buf, "var %s = map[string]int{\n", name)
+	terms := outputTerms(sortedTerms)
+	for i, term := range sortedTerms {
+		fmt.Fprintf(buf, "%s: %d,\n", strconv.Quote(terms[i]), freqMap[term]) // Quote escapes any byte sequence
+	}
+	buf.WriteString("}\n")
+}
+
+// Helper function to derive the variable name from the output file name,
+// e.g. "deduplicated_english.go" declares deduplicatedEnglish
+func goIdentifier(filePath string) string {
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = b.Len() > 0
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 || unicode.IsDigit([]rune(b.String())[0]) {
+		return "frequencies" + b.String()
+	}
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Flag to rewrite the golden files from the current output: go test -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Environment variable that makes the test binary run the program instead of the tests
+const runMainEnv = "GOLDEN_RUN_MAIN"
+
+// Function to run the program in place of the tests when the golden test re-executes itself
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Golden test cases: an input of testdata and the options it is analyzed with. The output
+// files are compared with testdata/<name>/<output file>.golden.
+var goldenCases = []struct {
+	name  string
+	input string
+	args  []string
+}{
+	{"english", "english.txt", nil},
+	{"chinese", "chinese.txt", nil},
+	{"mixed", "mixed.txt", nil},
+	{"english_reports", "english.txt", []string{"-normalize-digits", "-cooccurrence", "2", "-tfidf", "-document-frequency",
+		"-mutual-information", "-mutual-information-min", "1", "-vocab"}},
+	{"mixed_groups", "mixed.txt", []string{"-group-by-initial", "-by-count", "-band", "2", "-longest", "3"}},
+	{"english_bands", "english.txt", []string{"-lang", "en", "-word-bands"}},
+	{"english_kwic", "english.txt", []string{"-lang", "en", "-kwic", "2"}},
+	{"entities", "entities.txt", []string{"-urls", "-social", "-roman-numerals", "-longest", "10"}},
+	{"surface", "surface.txt", []string{"-lang", "en", "-surface-forms", "-by-count", "-band", "2"}},
+}
+
+// Function to check every output file the program writes for each golden case
+func TestGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			args := append([]string{"-input", filepath.Join("testdata", c.input), "-outdir", outDir}, c.args...)
+			cmd := exec.Command(os.Args[0], args...)
+			cmd.Env = append(os.Environ(), runMainEnv+"=1")
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("running %v: %v\n%s", args, err, output)
+			}
+
+			got := readOutputs(t, outDir)
+			if len(got) == 0 {
+				t.Fatalf("no output files written:\n%s", output)
+			}
+			goldenDir := filepath.Join("testdata", c.name)
+			if *update {
+				if err := os.RemoveAll(goldenDir); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(goldenDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				for name, data := range got {
+					if err := os.WriteFile(filepath.Join(goldenDir, name+".golden"), data, 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return
+			}
+
+			want := readOutputs(t, goldenDir)
+			for name, data := range want {
+				name = strings.TrimSuffix(name, ".golden")
+				if _, ok := got[name]; !ok {
+					t.Errorf("%s was not written (run go test -update to accept)", name)
+				} else if !bytes.Equal(got[name], data) {
+					t.Errorf("%s differs from its golden file (run go test -update to accept):\n%s", name, got[name])
+				}
+				delete(got, name)
+			}
+			for _, name := range sortedNames(got) {
+				t.Errorf("%s has no golden file (run go test -update to accept)", name)
+			}
+		})
+	}
+}
+
+// Helper function to read the files of a directory, keyed by name
+func readOutputs(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = data
+	}
+	return files
+}
+
+// Helper function to list the names of a file map in alphabetical order
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Function to format co-occurrence pairs as an undirected GraphML graph for Gephi or Cytoscape
+// (-format graphml): a node per word, labeled with the word and carrying its frequency, and an
+// edge per pair weighted by its co-occurrence count. Nodes are numbered by descending frequency.
+func formatGraphML(pairFreq, wordFreq map[string]int) []string {
+	// Collect the words of the kept pairs
+	nodeFreq := make(map[string]int)
+	for pair := range pairFreq {
+		for _, word := range strings.SplitN(pair, " ", 2) {
+			nodeFreq[word] = wordFreq[word]
+		}
+	}
+	nodes := sortByFrequency(nodeFreq)
+	ids := make(map[string]int, len(nodes))
+
+	lines := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`,
+		`  <key id="label" for="node" attr.name="label" attr.type="string"/>`,
+		`  <key id="frequency" for="node" attr.name="frequency" attr.type="int"/>`,
+		`  <key id="weight" for="edge" attr.name="weight" attr.type="int"/>`,
+		`  <graph id="cooccurrence" edgedefault="undirected">`,
+	}
+	for i, label := range outputTerms(nodes) {
+		ids[nodes[i]] = i
+		lines = append(lines, fmt.Sprintf(`    <node id="n%d"><data key="label">%s</data><data key="frequency">%d</data></node>`,
+			i, escapeXML(label), nodeFreq[nodes[i]]))
+	}
+	for _, pair := range sortByFrequency(pairFreq) {
+		words := strings.SplitN(pair, " ", 2)
+		lines = append(lines, fmt.Sprintf(`    <edge source="n%d" target="n%d"><data key="weight">%d</data></edge>`,
+			ids[words[0]], ids[words[1]], pairFreq[pair]))
+	}
+	return append(lines, "  </graph>", "</graphml>")
+}
+
+// Helper function to escape text for XML character data
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
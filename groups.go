@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
+	textlang "golang.org/x/text/language" // Aliased: language is the -lang flag
+)
+
+// Collator of -collate, nil for the default byte-wise order
+var collator *collate.Collator
+
+// Function to set up locale-aware sorting for a -collate locale such as "fr" or "de";
+// "root" selects the CLDR root collation and "" keeps the byte-wise order
+func setCollation(locale string) error {
+	if locale == "" {
+		return nil
+	}
+	tag := textlang.Und
+	if locale != "root" {
+		var err error
+		if tag, err = textlang.Parse(locale); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidOption, err)
+		}
+	}
+	collator = collate.New(tag)
+	return nil
+}
+
+// Helper function to sort strings alphabetically, by -collate rules if set
+func sortAlpha(terms []string) {
+	if collator != nil {
+		collator.SortStrings(terms)
+		return
+	}
+	sort.Strings(terms)
+}
+
+// Helper function to get the section key of a term: its first character, upper-cased for
+// Latin letters. (Chinese terms are grouped by their first character, not by pinyin initial,
+// which would need a pinyin dictionary.)
+func termInitial(term string) string {
+	first, _ := utf8.DecodeRuneInString(term)
+	return string(unicode.ToUpper(first))
+}
+
+// Function to organize frequency-sorted terms into "== X ==" sections by initial, sections in
+// alphabetical order and terms within each section by frequency, or alphabetically if alpha is set.
+// Initials are taken from the written terms, so with -hash-terms they are hex digits of the hashes.
+func groupByInitial(sortedTerms []string, alpha bool) []string {
+	sections := make(map[string][]string)
+	var initials []string
+	for _, term := range outputTerms(sortedTerms) {
+		initial := termInitial(term)
+		if _, ok := sections[initial]; !ok {
+			initials = append(initials, initial)
+		}
+		sections[initial] = append(sections[initial], term)
+	}
+	sortAlpha(initials)
+
+	var lines []string
+	for _, initial := range initials {
+		terms := sections[initial]
+		if alpha {
+			sortAlpha(terms)
+		}
+		lines = append(lines, fmt.Sprintf("== %s ==", initial))
+		lines = append(lines, terms...)
+	}
+	return lines
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Cyrillic and Greek letters that look like Latin ones, with their Latin form; a subset of the
+// Unicode confusables (https://www.unicode.org/Public/security/latest/confusables.txt) covering
+// the letters used in practice to spoof Latin words
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'ӏ': 'l', 'о': 'o',
+	'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'у': 'y', 'ԝ': 'w', 'х': 'x',
+	'А': 'A', 'В': 'B', 'С': 'C', 'Е': 'E', 'Н': 'H', 'І': 'I', 'Ј': 'J', 'К': 'K', 'М': 'M',
+	'О': 'O', 'Р': 'P', 'Ѕ': 'S', 'Т': 'T', 'Х': 'X', 'У': 'Y',
+	// Greek
+	'α': 'a', 'ι': 'i', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N',
+	'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+}
+
+// Matches runs of letters, the words homoglyphs are merged in
+var letterRunPattern = regexp.MustCompile(`[\p{L}\p{M}]+`)
+
+// Function to map look-alike Cyrillic and Greek letters to Latin in words that also contain Latin
+// letters (-merge-homoglyphs), so a spoofed "pаypаl" with Cyrillic "а" counts as "paypal".
+// Words without Latin letters are genuine Cyrillic or Greek text and are left alone.
+func mergeHomoglyphs(line string) string {
+	return letterRunPattern.ReplaceAllStringFunc(line, func(word string) string {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.Is(unicode.Latin, r) }) < 0 {
+			return word
+		}
+		return strings.Map(func(r rune) rune {
+			if latin, ok := homoglyphs[r]; ok {
+				return latin
+			}
+			return r
+		}, word)
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Matches identifiers like "get_user_name" and "getUserName" as English words with -split-identifiers;
+// the English word regex alone has no word boundary inside snake_case
+const identifierRegex = `\b[a-zA-Z0-9_']+(?:-[a-zA-Z0-9_']+)?\b`
+
+// Compiled identifierRegex
+var identifierPattern = regexp.MustCompile(identifierRegex)
+
+// Function to split an identifier into its words (-split-identifiers): at underscores and at
+// camelCase humps, keeping acronym runs together, so "getUserName" gives get, User, Name,
+// "HTTPServer" gives HTTP, Server and "parse_URL_v2" gives parse, URL, v2. Digits stay with the
+// preceding letters. Returns nil for identifiers made of underscores only.
+func splitIdentifier(identifier string) []string {
+	var words []string
+	for _, part := range strings.Split(identifier, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) && runes[i-1] != '-' && runes[i-1] != '\''
+			acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+	return words
+}
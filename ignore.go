@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Name of the optional ignore file read from the root of an input directory
+const ignoreFileName = ".txtfreqignore"
+
+// Pattern of an ignore file, following .gitignore semantics
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool // "!pattern" re-includes a path excluded by an earlier pattern
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// Function to read the ignore rules of a directory; a missing ignore file means no rules
+func loadIgnoreRules(dir string) ([]ignoreRule, error) {
+	path := filepath.Join(dir, ignoreFileName)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+
+		// A pattern without an inner slash matches a name at any depth; one with a slash
+		// is anchored at the directory holding the ignore file
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		if rule.pattern, err = regexp.Compile("^" + globToRegex(strings.TrimPrefix(line, "/")) + "$"); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q: %w", path, lineNumber, scanner.Text(), err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// Helper function to translate a gitignore glob into a regular expression: "*" and "?" don't
+// match "/", "**/" matches any number of directories and a trailing "/**" everything inside
+func globToRegex(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				class := glob[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+			} else {
+				b.WriteString(`\[`)
+			}
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Function to check a slash-separated path relative to the ignore file's directory;
+// the last matching rule decides, as in .gitignore
+func isIgnored(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Function to collect the text files below a directory, skipping paths matched by its
+// ignore file. An ignored directory is not descended into, so a negated pattern cannot
+// re-include files inside it (like git).
+func walkInputDir(root string) (files []string, excluded int, err error) {
+	rules, err := loadIgnoreRules(root)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if isIgnored(rules, filepath.ToSlash(rel), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			excluded++
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !strings.EqualFold(filepath.Ext(path), ".txt") {
+			return nil
+		}
+
+		if isExcluded(path) || isOutputFile(path, root) {
+			excluded++
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, excluded, err
+}
@@ -0,0 +1,73 @@
+package main
+
+import "encoding/json"
+
+// Line a term occurred on, recorded with -kwic and -inverted-index. Only the position is kept;
+// the concordance reads the line itself back from the input file.
+type lineRef struct {
+	Path string
+	Line int // 1-based, counting every line of the file
+}
+
+// Function to record that a Chinese character or English word occurs on the current line: every
+// distinct line for the inverted index, the first kwicLinesPerTerm for the concordance
+func (a *analysis) recordLine(term string) {
+	appendRef := func(refs []lineRef) []lineRef {
+		if len(refs) > 0 && refs[len(refs)-1] == a.lineAt {
+			return refs // Lines are recorded in order, so a repeat on the line is the last one
+		}
+		return append(refs, a.lineAt)
+	}
+	if a.lineIndex != nil {
+		a.lineIndex[term] = appendRef(a.lineIndex[term])
+	}
+	if a.kwicLines != nil && len(a.kwicLines[term]) < kwicLinesPerTerm {
+		a.kwicLines[term] = appendRef(a.kwicLines[term])
+	}
+}
+
+// Function to format the inverted index (-inverted-index) as JSON: per category (keyed like the
+// JSON results), each term maps to its input files and the sorted line numbers it occurs on, e.g.
+// {"english_words": {"apple": {"a.txt": [1, 3]}}}. Keys are sorted, so the output is stable.
+// With -position-buckets, bucket numbers replace the line numbers.
+func formatInvertedIndex(a *analysis) ([]byte, error) {
+	fileLines := make(map[string]int)
+	for _, f := range a.files {
+		fileLines[f.Path] = f.Lines
+	}
+	index := make(map[string]map[string]map[string][]int)
+	add := func(category string, freqMap map[string]int) {
+		terms := make(map[string]map[string][]int)
+		for term := range freqMap {
+			files := make(map[string][]int)
+			for _, ref := range a.lineIndex[term] {
+				position := ref.Line
+				if *positionBuckets > 0 {
+					position = lineBucket(ref.Line, fileLines[ref.Path], *positionBuckets)
+				}
+				if positions := files[ref.Path]; len(positions) == 0 || positions[len(positions)-1] != position {
+					files[ref.Path] = append(positions, position)
+				}
+			}
+			terms[outputTerms([]string{term})[0]] = files
+		}
+		index[category] = terms
+	}
+	if runChinese {
+		add("chinese_characters", a.chineseCharFreq)
+	}
+	if runEnglish {
+		add("english_words", a.englishWordFreq)
+	}
+	return json.Marshal(index)
+}
+
+// Helper function to map a 1-based line of a file with total lines to one of n equal ranges
+// (-position-buckets): line l falls into bucket (l-1)*n/total + 1, so with n = 3 the first third
+// of the lines is bucket 1 and the last third bucket 3
+func lineBucket(line, total, n int) int {
+	if total < 1 {
+		return 1
+	}
+	return (line-1)*n/total + 1
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Function to read the sample of a file used for detection: its first langSampleBytes bytes
+func readSample(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, langSampleBytes))
+}
+
+// Helper function to guess the encoding of a sample from its byte order mark and UTF-8 validity.
+// Invalid bytes cut off by the end of the sample are not counted.
+func detectEncoding(sample []byte) string {
+	switch {
+	case len(sample) >= 2 && (sample[0] == 0xFF && sample[1] == 0xFE || sample[0] == 0xFE && sample[1] == 0xFF):
+		return "UTF-16 (byte order mark; convert the file to UTF-8)"
+	case len(sample) >= 3 && sample[0] == 0xEF && sample[1] == 0xBB && sample[2] == 0xBF:
+		return "UTF-8 with byte order mark"
+	}
+	invalid, truncated := 0, len(sample) == langSampleBytes
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		if r == utf8.RuneError && size == 1 && (!truncated || len(sample)-i >= utf8.UTFMax) {
+			invalid++
+		}
+		i += size
+	}
+	if invalid > 0 {
+		return fmt.Sprintf("not valid UTF-8 (%d invalid bytes; maybe GBK, Big5 or Latin-1, convert the file to UTF-8)", invalid)
+	}
+	return "UTF-8"
+}
+
+// Helper function to count the letters of a sample per Unicode script
+func scriptComposition(sample []byte) (map[string]int, int) {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range string(sample) {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		name := "other"
+		for script, table := range unicode.Scripts {
+			if unicode.Is(table, r) {
+				name = script
+				break
+			}
+		}
+		counts[name]++
+	}
+	return counts, letters
+}
+
+// Function to print a diagnostic of each input file (-inspect): the guessed encoding, the language
+// -lang auto would choose with the share of Han and Latin letters behind it, and the letters'
+// script composition, all from the sample language detection reads
+func inspectFiles(paths []string) error {
+	for _, path := range paths {
+		sample, err := readSample(path)
+		if err != nil {
+			return err
+		}
+		counts, letters := scriptComposition(sample)
+
+		fmt.Printf("%s:\n", path)
+		fmt.Printf("  Sample: %d bytes\n", len(sample))
+		fmt.Printf("  Encoding: %s\n", detectEncoding(sample))
+		han, latin := counts["Han"], counts["Latin"]
+		if han+latin > 0 {
+			fmt.Printf("  Language: %s (Han %.1f%%, Latin %.1f%% of Han and Latin letters)\n", languageFromCounts(han, latin),
+				100*float64(han)/float64(han+latin), 100*float64(latin)/float64(han+latin))
+		} else {
+			fmt.Printf("  Language: %s (no Han or Latin letters)\n", languageFromCounts(han, latin))
+		}
+
+		// Scripts by share of the letters, ties by name
+		scripts := make([]string, 0, len(counts))
+		for script := range counts {
+			scripts = append(scripts, script)
+		}
+		sort.Slice(scripts, func(i, j int) bool {
+			if counts[scripts[i]] != counts[scripts[j]] {
+				return counts[scripts[i]] > counts[scripts[j]]
+			}
+			return scripts[i] < scripts[j]
+		})
+		fmt.Printf("  Letters: %d\n", letters)
+		for _, script := range scripts {
+			fmt.Printf("    %-12s %5.1f%%\n", script, 100*float64(counts[script])/float64(letters))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Bounds of the -kwic concordance: lines shown per term, and runes of context on each side
+const (
+	kwicLinesPerTerm = 5
+	kwicContext      = 30
+)
+
+// Function to format a keyword-in-context concordance of the n most frequent Chinese characters
+// and English words: for each term, the lines it occurs on with the match in brackets
+func formatKwic(a *analysis, n int) ([]string, error) {
+	type category struct {
+		label   string
+		freqMap map[string]int
+		enabled bool
+		words   bool // Terms are matched as whole words
+	}
+	categories := []category{
+		{"Chinese characters", a.chineseCharFreq, runChinese, false},
+		{"English words", a.englishWordFreq, runEnglish, true},
+	}
+
+	// Collect the top terms and the lines to read back
+	top := make([][]string, len(categories))
+	needed := make(map[string]map[int]string)
+	for i, c := range categories {
+		if !c.enabled {
+			continue
+		}
+		if top[i] = sortByFrequency(c.freqMap); len(top[i]) > n {
+			top[i] = top[i][:n]
+		}
+		for _, term := range top[i] {
+			for _, ref := range a.kwicLines[term] {
+				if needed[ref.Path] == nil {
+					needed[ref.Path] = make(map[int]string)
+				}
+				needed[ref.Path][ref.Line] = ""
+			}
+		}
+	}
+	for path, lines := range needed {
+		if err := readLines(path, lines); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []string
+	for i, c := range categories {
+		if !c.enabled {
+			continue
+		}
+		out = append(out, fmt.Sprintf("== %s ==", c.label))
+		for j, term := range outputTerms(top[i]) {
+			out = append(out, fmt.Sprintf("%s (%d)", term, c.freqMap[top[i][j]]))
+			pattern := kwicPattern(top[i][j], c.words)
+			for _, ref := range a.kwicLines[top[i][j]] {
+				out = append(out, fmt.Sprintf("  %s:%d: %s", ref.Path, ref.Line, kwicWindow(needed[ref.Path][ref.Line], pattern)))
+			}
+		}
+	}
+	return out, nil
+}
+
+// Helper function to read the given line numbers of a file into lines
+func readLines(path string, lines map[int]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+	scanner.Split(scanLines) // Numbered like the analysis
+	for number := 1; scanner.Scan(); number++ {
+		if _, ok := lines[number]; ok {
+			lines[number] = scanner.Text()
+		}
+	}
+	return scanner.Err()
+}
+
+// Helper function to compile the case-insensitive pattern of a term, matching whole words only
+// if words is set
+func kwicPattern(term string, words bool) *regexp.Regexp {
+	expr := regexp.QuoteMeta(term)
+	if words {
+		expr = `\b` + expr + `\b`
+	}
+	return regexp.MustCompile(`(?i)` + expr)
+}
+
+// Helper function to cut the context around the first match of a kwicPattern out of line,
+// marking the match with brackets. Terms changed by normalization (e.g. <NUM>) may not be found
+// verbatim; the start of the line is shown then.
+func kwicWindow(line string, pattern *regexp.Regexp) string {
+	line = strings.ReplaceAll(line, "\t", " ")
+	loc := pattern.FindStringIndex(line)
+	if loc == nil {
+		return truncateRunes(strings.TrimSpace(line), 2*kwicContext, false)
+	}
+	left := truncateRunes(strings.TrimLeft(line[:loc[0]], " "), kwicContext, true)
+	right := truncateRunes(strings.TrimRight(line[loc[1]:], " "), kwicContext, false)
+	return left + "[" + line[loc[0]:loc[1]] + "]" + right
+}
+
+// Helper function to keep at most n runes of s, its last ones if fromEnd, marking a cut with "..."
+func truncateRunes(s string, n int, fromEnd bool) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if fromEnd {
+		return "..." + string(runes[len(runes)-n:])
+	}
+	return string(runes[:n]) + "..."
+}
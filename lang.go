@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Bytes sampled from the start of each input file for language detection
+const langSampleBytes = 64 << 10
+
+// Which category sets are analyzed; narrowed by -lang
+var (
+	runChinese = true
+	runEnglish = true
+)
+
+// Function to detect whether the inputs are Chinese ("zh"), English ("en") or both ("both")
+// from the share of Han characters among the Han and Latin letters of a sample of each file.
+// Only the choice between the Chinese and English category sets matters here, so the script
+// mix is a sufficient (and dependency-free) signal.
+func detectLanguage(paths []string) (string, error) {
+	han, latin := 0, 0
+	for _, path := range paths {
+		sample, err := readSample(path)
+		if err != nil {
+			return "", err
+		}
+
+		for _, r := range string(sample) {
+			switch {
+			case unicode.Is(unicode.Han, r):
+				han++
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			}
+		}
+	}
+
+	return languageFromCounts(han, latin), nil
+}
+
+// Helper function to decide the language from Han and Latin letter counts: a language is
+// dropped when it makes up less than 10% of the letters
+func languageFromCounts(han, latin int) string {
+	total := han + latin
+	switch {
+	case total == 0:
+		return "both"
+	case float64(han)/float64(total) >= 0.9:
+		return "zh"
+	case float64(latin)/float64(total) >= 0.9:
+		return "en"
+	default:
+		return "both"
+	}
+}
+
+// Function to select the category sets to run for a language
+func setLanguage(lang string) error {
+	switch lang {
+	case "zh":
+		runChinese, runEnglish = true, false
+	case "en":
+		runChinese, runEnglish = false, true
+	case "both", "auto":
+		runChinese, runEnglish = true, true
+	default:
+		return fmt.Errorf("%w: unsupported language %q (use auto, zh, en or both)", ErrInvalidOption, lang)
+	}
+	return nil
+}
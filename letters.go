@@ -0,0 +1,28 @@
+package main
+
+import "unicode"
+
+// Helper function to check whether a term has content: at least one letter (Han characters
+// are letters too). Digits alone, punctuation and symbols are not content.
+func hasLetter(term string) bool {
+	for _, r := range term {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to remove the terms without content from the word and phrase counts (-require-letter),
+// such as "--", "2024" or "1 - 2", returning how many were removed. The duplicated lists keep them.
+func (a *analysis) dropLetterless() (dropped int) {
+	for _, freqMap := range []map[string]int{a.chineseWordsFreq, a.englishWordFreq, a.englishPhrasesFreq, a.scriptFreq} {
+		for term := range freqMap {
+			if !hasLetter(term) {
+				delete(freqMap, term)
+				dropped++
+			}
+		}
+	}
+	return dropped
+}
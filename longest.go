@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Function to get the n longest distinct terms of a frequency map by character (rune) count,
+// so a CJK character counts as 1 rather than its 3 UTF-8 bytes.
+// Length ties are broken by frequency (descending), then alphabetically.
+func longestTerms(freqMap map[string]int, n int) []string {
+	terms := make([]string, 0, len(freqMap))
+	for term := range freqMap {
+		terms = append(terms, term)
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(terms[i]), utf8.RuneCountInString(terms[j])
+		if li != lj {
+			return li > lj
+		}
+		if freqMap[terms[i]] != freqMap[terms[j]] {
+			return freqMap[terms[i]] > freqMap[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// Function to format the longest terms of each category as "== category ==" sections
+// of tab-separated "term length count" lines (phrases contain spaces). The length is the
+// rune count; with -byte-lengths a UTF-8 byte length column follows it.
+func formatLongest(a *analysis, n int) []string {
+	categories := []struct {
+		name    string
+		freqMap map[string]int
+	}{
+		{"Chinese characters", a.chineseCharFreq},
+		{"Chinese words", a.chineseWordsFreq},
+		{"English words", a.englishWordFreq},
+		{"English phrases", a.englishPhrasesFreq},
+	}
+
+	var lines []string
+	for _, c := range categories {
+		lines = append(lines, fmt.Sprintf("== %s ==", c.name))
+		longest := longestTerms(c.freqMap, n)
+		for i, term := range outputTerms(longest) {
+			length := fmt.Sprint(utf8.RuneCountInString(longest[i]))
+			if *byteLengths {
+				length += fmt.Sprintf("\t%d", len(longest[i]))
+			}
+			lines = append(lines, fmt.Sprintf("%s\t%s\t%d", term, length, c.freqMap[longest[i]]))
+		}
+	}
+	return lines
+}
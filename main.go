@@ -2,11 +2,18 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/sqweek/dialog"
 )
@@ -19,11 +26,16 @@ Features:
 - GUI-based file selection for ease of use.
 - Categorizes text into Chinese characters, Chinese words, English words, and English phrases.
 - Generates frequency-based deduplicated outputs and preserves original order for duplicated elements.
-- Supports regex-based text processing and sorting by frequency.
+- Supports regex-based text processing and sorting by frequency. Terms with equal frequency are
+  ordered by their UTF-8 bytes (never by the system locale), so the same input always produces
+  byte-for-byte identical output.
 
 Workflow:
-1. Users select an input file via a GUI dialog.
-2. The program reads the input, categorizing Chinese and English text using regex patterns:
+1. Users select an input file via `-input`, a file argument (e.g. dragged onto the executable) or a
+   GUI dialog.
+2. The program reads the input line by line, categorizing Chinese and English text using regex
+   patterns. Lines end with `\n`, `\r\n` or a lone `\r` (old Mac files), which is never
+   trailed by a newline:
    - Chinese characters and words.
    - English words and phrases.
 3. Frequency maps and original lists are constructed for text elements.
@@ -31,127 +43,1333 @@ Workflow:
    - `deduplicated_chinese.txt` and `deduplicated_english.txt`.
 5. Raw duplicated data is saved preserving original order:
    - `duplicated_chinese.txt` and `duplicated_english.txt`.
-6. All outputs are written and saved with success notifications.
+   URLs and email addresses are recognized before the English regexes run and excluded from
+   the word and phrase counts; each one ends the phrase it interrupts. With `-urls` they are
+   saved by frequency to `urls.txt` and `emails.txt` (sentence punctuation trailing a URL is not
+   part of it; emails are case-folded).
+6. All outputs are written and saved with success notifications. Each file is written under a
+   temporary name and renamed into place, so an interrupted run never leaves a half-written file.
+7. A summary report is printed with vocabulary richness metrics (type-token ratio,
+   root TTR, Herdan's C and fitted Heaps' law parameters) for English and Chinese words, and
+   English readability scores (syllables per word, Flesch Reading Ease, Flesch-Kincaid Grade Level)
+   based on heuristic sentence and syllable counts.
+
+Options:
+Invalid values and combinations are reported before any input is read: options that have no
+effect without another one (e.g. `-salt` without `-hash-terms`, `-columns` without `-top`) and
+options that exclude each other (e.g. `-external` with `-workers`, `-group-by-initial` with a
+non-text `-format`).
+- `-input`: input file to analyze; when omitted, a positional file argument is used (so dropping a file
+  onto the executable analyzes it; options must precede it), and without one a GUI dialog asks for
+  it. A glob pattern such as `-input 'data/*.txt'` is expanded by the program itself and all matched
+  files are aggregated. A directory is walked recursively and all its `.txt` files are aggregated;
+  a `.txtfreqignore` file at its top skips files and folders using `.gitignore` syntax (`#`
+  comments, `*`, `?`, `[...]`, `**`, `!` to re-include, a trailing `/` for directories only, a
+  leading or inner `/` to anchor the pattern at the top; the last matching line wins).
+- `-exclude`: glob pattern of matched input files to skip, tested against the file name and the
+  whole path (repeatable, or comma-separated), e.g. `-exclude 'README*'`. The program's own output
+  files (`deduplicated_*.txt`, `duplicated_*.txt`, ... and anything inside a separate `-outdir`)
+  are always skipped so repeated runs don't count previous results.
+- `-outdir`: directory the output files are written to (default: the folder of the input file, or
+  of the non-wildcard part of an `-input` pattern, so GUI users find the results next to their file).
+- `-format`: format of the deduplicated outputs. `txt` (default) writes one term per line;
+  `parquet` writes `deduplicated_<category>.parquet` files with the columns term (string) and
+  count (int64), for Spark/pandas pipelines. `go` writes `deduplicated_<category>.go`, a gofmt'ed
+  source file of package `frequencies` declaring e.g. `var deduplicatedEnglish = map[string]int{...}`
+  (the variable is named after the file so all outputs compile together). `msgpack` writes
+  `deduplicated_<category>.msgpack`, a MessagePack array of `{"term": ..., "count": ...}` maps
+  shaped like a category of the `-serve` JSON results, compact and fast to parse for services.
+  `graphml` writes the `-cooccurrence` pairs as `cooccurrence.graphml` instead of
+  `cooccurrence.txt`, for network visualization in Gephi or Cytoscape: an undirected graph with a
+  node per word (`label` and `frequency` attributes) and an edge per pair (`weight` attribute, the
+  co-occurrence count); the deduplicated outputs stay text. Requires `-cooccurrence`.
+  Duplicated lists are always written as text.
+- `-stdout`: write the deduplicated Chinese characters and English words to standard output
+  instead of the deduplicated and duplicated files, for use as a filter in a pipeline; all
+  messages and the summary go to standard error. In the `-format` format: `txt` lines as in the
+  files, each category preceded by a `== chinese_characters ==` or `== english_words ==` line
+  when both are written (`-lang` selects one); `go` one source file with a variable per category;
+  `msgpack` one value, a map from the category names to their arrays when both are written.
+  Optional outputs (`-cooccurrence`, ...) are still written to files. Not available with `parquet`.
+- `-retries N`: when opening or reading an input file fails with an error that may be transient,
+  as on NFS or SMB mounts (interrupted calls, busy resources, timeouts, stale handles, I/O errors),
+  try again up to N times, waiting 100ms before the first retry and twice as long before each
+  further one. A failed read reopens the file and continues at the byte where it stopped, so
+  nothing is counted twice. Any other error, such as a missing file, denied permission or a
+  directory given as input, fails at once. Default 0 (no retries).
+- `-progress`: show how much of the input has been read, with an ETA extrapolated from the
+  throughput so far. The total is taken from the input file sizes, so no extra pass is needed.
+- `-flush-interval N|DURATION`: during a long scan, periodically rewrite the deduplicated Chinese
+  and English outputs with the frequencies counted so far, every N analyzed lines (e.g. `100000`) or
+  every DURATION (e.g. `30s`), for near-real-time monitoring. Snapshots are written to a temporary
+  file and renamed into place, so readers never see a partially written file.
+- `-external`: count inputs whose vocabulary exceeds the available memory. Terms are streamed to the
+  duplicated outputs as they are read, and each category holds at most `-external-max-terms`
+  distinct terms (default 1000000) in memory; beyond that the counts are spilled to sorted run
+  files in a temporary folder of the output directory and merged (an external merge sort) when
+  the scan ends. Only the deduplicated and duplicated Chinese character and English word outputs
+  are produced, and the summary reports the distinct terms against the number held in memory.
+  Options for other outputs and reports are ignored.
+- `-workers N`: analyze up to N input files at the same time (default 1), each into its own
+  counts, which are merged in input order as files finish. Results, including the order of the
+  duplicated lists, are identical to a sequential run. Can't be combined with `-flush-interval`,
+  `-checkpoint-every` or `-resume`.
+- `-threads-io N`: separate reading from analyzing: one goroutine reads the lines of each input
+  while N goroutines tokenize them, so waiting for slow storage overlaps with the regex work.
+  Lines are handed over in batches of 256, each tagged by its position, and the counts of the
+  batches are merged in input order, so the results, including the order of the duplicated
+  lists, are identical to a run without it. `-io-buffer N` (default 4096) bounds how many lines
+  are read ahead of the merge. Can't be combined with `-flush-interval`, `-checkpoint-every`,
+  `-resume`, `-external`, `-section-regex` or `-per-line-stats`, which need each line's counts
+  as soon as it is read.
+- `-checkpoint-every N`, `-resume`: for very large inputs, save the frequency state and the byte
+  offset reached to `.checkpoint` in the output directory (gob-encoded) every N analyzed lines. After
+  a crash, re-running with the same options plus `-resume` continues where the checkpoint left off.
+  The checkpoint records the SHA-256 of every input and is rejected if an input changed. It is
+  deleted once a run completes.
+- `-compress`: write output files gzip-compressed, adding a `.gz` suffix (e.g. `duplicated_english.txt.gz`).
+- `-lang auto|zh|en|both`: analyze only the Chinese (`zh`) or English (`en`) categories, or both
+  (default). `auto` samples the start of each input and drops a language making up less than 10%
+  of its Han and Latin letters; the summary reports the detected language.
+- `-validate`: sanity-check the tokenizer after scanning: for each category with a duplicated
+  list (Chinese characters and words, English words and phrases, URLs, emails, hashtags,
+  mentions), verify that its frequency counts sum to the number of tokens in the list, and print
+  "pass" or "FAIL" with both numbers. A failure means the two code paths diverged. Runs before
+  options that remove counted terms (`-require-letter`, `-round-counts`, ...). Can't be combined
+  with `-weighted` or `-split-identifiers`, whose counts differ from the lists by design, or with
+  `-external`, which keeps no lists.
+- `-list-categories`, `-list-formats`: print the token categories the analysis counts, or the
+  output formats `-format` accepts, one per line with a short description, and exit.
+- `-inspect`: check how the inputs would be read before a full run: for each input file, report
+  from the same sample `-lang auto` reads (the first 64 KiB) the guessed encoding (UTF-8, UTF-8
+  with byte order mark, UTF-16, or invalid UTF-8 with the count of invalid bytes), the language
+  `-lang auto` would choose with the Han/Latin shares behind it, and the share of each Unicode
+  script among the letters. Nothing is analyzed or written.
+- `-wc`: print quick counts of each input like Unix `wc -lwmc` and exit without writing files:
+  lines, words, characters (UTF-8 runes) and bytes, then the file name, plus a total row for
+  several files. Words are CJK-aware: English words as tokenized by the analysis plus Chinese
+  characters, each counting as one word (so "hello 世界" is 3 words). `-lang`, `-skip-lines` and
+  the line range apply to the words; lines, characters and bytes cover the whole file.
+- `-start-line`, `-end-line`: analyze only lines in this 1-based, inclusive range (an end of 0 means
+  the last line). Lines outside the range are read but not tokenized; the summary reports the range.
+- `-weighted`: read pre-aggregated input of `text<TAB>weight` lines (split at the last tab), where
+  every term of the text counts `weight` times in the frequencies, e.g. `new york<TAB>120`. Lines
+  without a positive integer weight are skipped and reported in the summary. Duplicated lists and
+  metrics derived from them (vocabulary richness, readability, co-occurrence, ...) see each term
+  once. Can't be combined with `-filter-cmd`, which recounts the duplicated lists.
+- `-chunk-size N`: analyze lines longer than N bytes in chunks of at most N bytes instead of
+  reading them whole, for huge single-line inputs (minified or extracted text); without it a line
+  longer than 64 KiB fails the scan. Chunks keep their line's number. To lose no term at a chunk
+  boundary, the last `-chunk-overlap` bytes (default 1024) of each chunk are searched for the
+  latest place to cut: after punctuation or a symbol (except `'`, `-` and `_`), which splits
+  neither words nor phrases; else after whitespace, which may split a phrase; else after the last
+  whole character. The text after the cut starts the next chunk, so terms in the overlap are
+  counted once. Can't be combined with options that need whole lines (`-weighted`,
+  `-line-frequency`, `-per-line-stats`, `-section-regex`).
+- `-skip-lines N`: skip the first N lines of each input file, such as a metadata header. Line numbers
+  keep counting the skipped lines, so with `-start-line` the later of the two starts wins. The
+  summary reports how many lines were skipped.
+- `-filter-cmd "CMD ARGS"`: pipe the English words and, separately, the Chinese words through an
+  external command (e.g. a lemmatizer) before they are counted. Protocol: the command reads one token
+  per line on stdin and writes exactly one line per input line to stdout, in order; an empty line
+  drops the token. The command line is split on spaces and run without a shell. Applied after the
+  scan, so `-per-line-stats` reflect the unfiltered words.
+- `-dedupe-case-insensitive`: lower-case the words of `duplicated_english.txt` too, matching the
+  case-folded counts of `deduplicated_english.txt` ("Apple" and "apple" are one term in both). By
+  default the duplicated list keeps the original casing.
+- `-surface-forms`: keep counting English words case-insensitively, but write each word of
+  `deduplicated_english.txt` (and `-stdout`) in the spelling it was most often seen in, e.g.
+  "iPhone" for 5 "iPhone", 2 "iphone" and 1 "IPHONE", counted 8 times. Ties go to the spelling
+  that sorts first byte-wise (upper-case first). The spellings of each word are tallied while
+  scanning; other outputs keep the lower-cased terms.
+- `-min-word-length N`: leave English words shorter than N characters (e.g. "a", "I" or stray OCR
+  letters) out of `deduplicated_english.txt`. They are still counted in the summary and the
+  duplicated list. The default 1 keeps every word; Chinese characters are unaffected.
+- `-max-term-length N`: discard Chinese words, English words and English phrases longer than N
+  characters (runes) before counting; default 256, 0 disables. This guards against runaway phrase
+  matches on malformed lines. The summary reports how many terms were dropped.
+- `-strip-chars CHARS`: remove each of these characters from every line before any regex runs,
+  e.g. `-strip-chars "·_"`. Characters are deleted, not replaced by spaces, so "foo_bar" becomes the
+  word "foobar" and a phrase is never split where a stripped character was.
+- `-strip-invisible` (default on): remove invisible characters that silently split or duplicate
+  tokens in text copied from the web before tokenizing: zero-width spaces (U+200B), word joiners
+  (U+2060), byte order marks in the middle of the text (U+FEFF) and soft hyphens (U+00AD), so
+  "中\u200b文" counts as the Chinese word "中文". Zero-width (non-)joiners, which shape scripts
+  like Persian and emoji, are kept. `-strip-invisible=false` analyzes the text as is.
+- `-split-hyphens`: treat hyphens as word separators, so "micro-video" counts as "micro" and "video"
+  (phrases see a space instead of the hyphen). Apostrophes are unaffected: "I'll" stays one word.
+- `-chinese-char-regex`, `-chinese-word-regex`, `-english-word-regex`, `-english-phrase-regex`
+  REGEX: replace the built-in pattern of a category (Go RE2 syntax), e.g. a stricter English word
+  definition like `-english-word-regex "\b[a-zA-Z]{2,}\b"` without digits or apostrophes. Unset
+  options keep the defaults; invalid patterns and patterns matching the empty string are
+  rejected. The other options (`-split-hyphens`, `-trim-punctuation`, ...) still apply to the
+  matches.
+- `-normalize-ligatures`: clean up text extracted from PDFs before tokenizing: apply Unicode NFKC
+  normalization, which splits ligatures ("ﬁnd" becomes "find") and maps full-width letters, digits
+  and punctuation to ASCII, then replace smart quotes with `'` and `"`, hyphens and en dashes with
+  `-` and em dashes with `--`, and remove soft hyphens and zero-width characters. Runs before
+  `-strip-chars`. Note that NFKC also rewrites compatibility characters in Chinese text, e.g.
+  full-width `，` becomes `,`.
+- `-fold-diacritics`: count English words and phrases with their accents removed, so "café" and
+  "cafe" (or "Noël" and "Noel") are one term: each counted term is decomposed (NFD), its combining
+  marks (Unicode category Mn) are dropped and the rest recomposed (NFC). Letters that don't
+  decompose, like "ø", "ł" or "ß", are kept. The duplicated lists keep the original spelling. The
+  default regex tokenizer only matches ASCII letters, so combine with `-tokenizer unicode` (or an
+  `-english-word-regex`) to count accented words whole.
+- `-tokenizer regex|unicode`: how English words are found. `regex` (default) uses the English
+  word regex (ASCII letters and digits, hyphenated compounds, apostrophes). `unicode` splits at
+  Unicode word boundaries instead, a simplified form of the UAX #29 rules: a word is a run of
+  letters, combining marks and digits of any script except Han, continuing across an apostrophe
+  between letters ("don't") and across "." or "," between digits ("3.14"); anything else,
+  hyphens included, separates words. Accented words like "café" then stay whole, and words of
+  other scripts (e.g. Cyrillic) count as English words too. Phrases still use the phrase regex.
+- `-split-identifiers`: for source code and identifier lists, count the words of camelCase and
+  snake_case identifiers instead of the identifiers: "getUserName" and "get_user_name" both count
+  get, user and name. Acronym runs stay together ("HTTPServer" counts http and server, "parseURL"
+  parse and url); digits stay with the preceding letters ("utf8Decoder" counts utf8 and decoder).
+  The duplicated English output keeps the original identifiers. Note that "McDonald" also splits.
+- `-merge-homoglyphs`: undo homoglyph spoofing before tokenizing: in words mixing Latin letters
+  with look-alike Cyrillic or Greek ones (from a table of Unicode confusables), map the look-alikes
+  to Latin, so "pаypаl" written with Cyrillic "а" counts as "paypal". Words without Latin
+  letters are genuine Cyrillic or Greek and are left alone, so spoofs made of look-alikes only
+  (e.g. an all-Cyrillic "сосо") are not merged. Runs after `-normalize-ligatures`.
+- `-normalize-chinese-numbers`: convert Chinese numbers to Arabic digits before counting, so "三"
+  and "3" both count as the English word "3". Common and formal forms (`壹贰叁`, `拾佰仟`) are
+  read: runs with units positionally ("三十五" is 35, "一百零五" 105, "两万三千" 23000), runs
+  without units digit by digit ("二〇二四" is 2024). Numeral characters inside words are converted
+  too ("一些" becomes "1些", "万一" 10001), so Chinese word counts change accordingly.
+- `-normalize-digits`: replace each run of digits, ASCII or full-width (`２０２０`), with the
+  placeholder `<NUM>` before counting, so "in 2020" and "in 1999" both count as "in <NUM>" and
+  "mp3" becomes "mp<NUM>". URLs and email addresses are extracted first and keep their digits.
+- `-trim-punctuation`: trim the characters `'"‘’“”-_.,;:!?()[]{}` from both ends of each English
+  word and phrase before counting, so "'quoted'" and "quoted" (or "dogs'" and "dogs") count as one
+  term. Tokens consisting only of punctuation are dropped; inner apostrophes and hyphens stay.
+- `-normalize-whitespace`: collapse tabs and repeated spaces inside English phrases to one space, so
+  "new   york" and "new york" count as the same phrase.
+- `-merge-whitespace-variants`: before counting an English phrase, replace every run of characters
+  other than letters, digits and apostrophes (spaces, tabs, hyphens, underscores, and with a
+  custom -english-phrase-regex any other punctuation) by a single space and trim the ends, so
+  "state-of-the-art", "state_of_the_art" and "state of the art" are one phrase. Apostrophes are
+  kept ("don't" stays whole). The duplicated phrase list keeps the original spelling.
+- `-require-letter`: drop words and phrases without content from the counts, where content means
+  at least one letter (any script; Han characters are letters): tokens of digits, punctuation or
+  symbols only, such as "''", "2024" or "1 - 2", are removed from the deduplicated outputs and
+  every other report. The duplicated lists keep them. The summary reports how many were dropped.
+- `-dedupe-subphrases`: remove English phrases that occur mostly inside a longer counted phrase.
+  A phrase is a sub-phrase of a longer one if its words are a contiguous run of the longer one's
+  words; it is removed when such a longer phrase is counted at least as often. For example, with
+  "thank you very much" counted 3 times and "thank you" 2 times, "thank you" is removed; counted 4
+  times, it is kept. Affects every phrase output (`-template`, `-longest`, `-query`).
+- `-chinese-combined`: write `chinese_combined.txt`, linking the character and word views: one line
+  per Chinese character, most frequent first, with its standalone count followed by the words
+  containing it and their counts, e.g. `中 12: 中文(5) 中国(4)`. Chinese words are runs of Han
+  characters (no segmentation), so in running text they can be whole clauses.
+- `-by-count`: additionally write `deduplicated_chinese_by_count.txt` and
+  `deduplicated_english_by_count.txt`, listing for each distinct count (highest first) all terms
+  with that count as a "count<TAB>term term ..." line, terms alphabetically. This shows ties and the
+  shape of the distribution at a glance.
+- `-shard`: for very large vocabularies, write each deduplicated output as one file per shard
+  instead of a single file: `deduplicated_english_a.txt`, `deduplicated_chinese_中.txt`, ... The
+  shard key is the first character of the written term, lower-cased, if it is a letter or digit
+  (so "Apple" and "apple" share `_a`, and "9lives" goes to `_9`), and `_` for any other character.
+  With `-hash-terms` the keys are the hashes' first hex digits. Each shard is sorted by frequency
+  and written in the `-format` format.
+- `-band B1,B2,...`: additionally split each deduplicated output into one file per frequency band.
+  For example `-band 100,10` writes `deduplicated_english_100plus.txt` (count >= 100),
+  `deduplicated_english_10-99.txt` and `deduplicated_english_1-9.txt`.
+- `-group-by-initial`: organize deduplicated text outputs into dictionary-style sections headed
+  "== A ==", one per initial (first letter for English, upper-cased; first character for Chinese,
+  as pinyin initials would need a pinyin dictionary). Sections appear in character order; terms
+  within a section by frequency, or alphabetically with `-group-order alpha`. With `-hash-terms`
+  the sections are the hashes' first hex digits.
+- `-collate LOCALE`: sort the `-group-by-initial` sections and `-group-order alpha` terms by the
+  collation rules of a locale (`fr`, `de`, ..., or `root` for the CLDR default) instead of by
+  UTF-8 bytes. For example `-collate zh` orders Chinese characters by pinyin, and any locale puts
+  "co-op" next to "coop". Frequency ties elsewhere are still broken byte-wise.
+- `-social`: count `#hashtags` and `@mentions` (including Unicode ones like `#中文` and full-width
+  `＃`/`＠`) as their own categories, case-folded, in `hashtags.txt` and `mentions.txt`, instead of
+  letting the English regex strip their prefixes. They only count at the start of a word, so "C#" is
+  not a hashtag.
+- `-urls`: also write the URLs and email addresses, which are always kept out of the English
+  counts, by frequency to `urls.txt` and `emails.txt`.
+- `-roman-numerals`: count Roman numerals such as "XIV" or "MCMXCIV" as their own category in
+  `roman_numerals.txt`, most frequent first, instead of as English words. Only upper-case runs of
+  two or more numeral letters count, and only in canonical form (1 to 3999), so the pronoun "I",
+  "mix" and "DID" stay words; upper-case words and abbreviations that happen to be valid numerals
+  ("MIX", "CD", "CV", "DC", "XL", ...) are left to the English words as well.
+- `-script NAME`: additionally count the words of any Unicode script, by its Go/Unicode name such
+  as `Cyrillic`, `Greek`, `Arabic` or `Devanagari` (case-sensitive; an unknown name lists the valid
+  ones). Words are runs of the script's letters with their combining marks, case-folded, written
+  by frequency to `script_<NAME>.txt` in the `-format` of the deduplicated outputs.
+- `-mixed-script`: report whitespace-separated tokens containing both Han characters and Latin
+  letters, such as "中文abc" or "app中", with their counts in `mixed_script.txt` (`count<TAB>token`),
+  most frequent first. Surrounding punctuation is trimmed. Such tokens are split between the Chinese
+  and English categories, so this shows how mixed content was tokenized and where loanwords occur.
+- `-line-frequency`: also count whole lines as tokens in `line_frequency.txt` (`count<TAB>line`), most
+  frequent first, to find repeated log entries or boilerplate. Trailing whitespace is ignored and blank lines are skipped;
+  word tokenization is unaffected.
+- `-dictionary FILE`: keep only terms found in this wordlist (one word per line, `#` comments allowed)
+  in the deduplicated outputs, e.g. to drop typos and OCR noise. Matching is case-insensitive, like
+  the English counts; duplicated lists are unaffected.
+- `-hash-terms`: replace every output term with the first 8 hex chars of its SHA-256 hash,
+  preserving ordering so frequency shapes can be shared without disclosing content.
+- `-round-counts N`, `-suppress-below M`: basic privacy protection when publishing frequency
+  statistics. Every count is rounded to the nearest multiple of N, and terms counted fewer than M
+  times (or rounding to 0) are left out of all deduplicated outputs and reports. This deliberately
+  reduces precision; the duplicated lists still contain every occurrence and shouldn't be published.
+  Reports counted from those lists (`-cooccurrence`, `-tfidf`, `-document-frequency`,
+  `-section-regex`, `-mutual-information`, `-seed-terms`) can't be combined with these options.
+  Combine with `-hash-terms` to hide the terms too.
+- `-salt`: secret prepended to each term before hashing, to prevent dictionary attacks.
+- `-cooccurrence N`: count order-independent pairs of English words appearing within N tokens
+  of each other in the same input file and write them to `cooccurrence.txt`. Pairs seen fewer
+  than `-cooccurrence-min` times are dropped (and pruned during counting once too many pairs are
+  held in memory).
+- `-mutual-information`: score adjacent English word pairs (bigrams) by pointwise mutual information,
+  log2(P(xy) / (P(x) P(y))), and write them to `mutual_information.txt` as "word1 word2 pmi count"
+  lines, highest PMI first. Unlike raw frequency this surfaces collocations ("hong kong") over
+  common pairs ("of the"). Bigrams seen fewer than `-mutual-information-min` times (default 3) are
+  skipped, as PMI overrates rare pairs.
+- `-vocab`: write `vocab.txt`, a vocabulary of the English (lower-cased) and Chinese words as
+  "id term count" lines with ids assigned by descending frequency, 0 for the most frequent term
+  (ties alphabetically), the usual first step for tokenizers and embedding pipelines.
+- `-tfidf`: when analyzing several files, weight each file's English and Chinese words by TF-IDF
+  (term frequency in the file times smoothed inverse document frequency across all input files)
+  and write one section per file to `tfidf.txt`, most distinctive terms first.
+- `-document-frequency`: when analyzing several files, write `document_frequency.txt` with one
+  "term total_count document_count" line per English (lower-cased) and Chinese word, where
+  document_count is the number of input files containing the term: the DF part of TF-IDF. Sorted
+  by document count, then total count, then alphabetically.
+- `-longest N`: write the N longest distinct terms of each category, regardless of frequency, to
+  `longest.txt` as tab-separated "term length count" lines (ties by frequency, then alphabetically).
+  Useful for spotting runaway phrase matches and long compounds.
+- `-byte-lengths`: add the UTF-8 byte length after the character length in length-based outputs.
+  All term lengths are measured in characters (runes, via `utf8.RuneCountInString`), so "中文" has
+  length 2, not its 6 bytes; byte lengths are only ever shown in this extra column.
+- `-cluster-distance D`: group English words within D edits (Levenshtein distance) of each other,
+  e.g. OCR variants like "hello" and "he1lo", and write clusters with two or more members to
+  `clusters.txt` as "representative combined_count member:count ..." lines. The representative is
+  the most frequent member. Only words sharing their first character and differing in length by at
+  most D are compared, which bounds the cost but misses errors in the first character.
+- `-kwic N`: keyword in context: write a concordance of the N most frequent Chinese characters
+  and English words to `kwic.txt`. Each term is followed by up to 5 of the lines it occurs on, as
+  `file:line: ...left context [term] right context...` with 30 characters of context on each
+  side. Only the line positions are kept while scanning; the lines are read back from the input
+  files when the concordance is written, so the inputs must not change in between. Can't be
+  combined with `-hash-terms`, as the context would show the terms.
+- `-inverted-index`: write `inverted_index.json`, a search index mapping each Chinese character
+  and English word to the sorted line numbers it occurs on, per input file:
+  `{"english_words": {"apple": {"a.txt": [1, 3]}}, "chinese_characters": {...}}`. Each line is
+  listed once per term; line numbers count every line of the file, as in `-kwic`.
+- `-position-buckets N`: in the inverted index, list for each term and file which of N equal
+  parts of the file it occurs in instead of its exact lines, to share where terms occur without
+  disclosing their lines. Line l of a file with T lines falls into bucket (l-1)*N/T + 1, so with
+  `-position-buckets 3` bucket 1 is the first third of the file, 2 the second and 3 the last;
+  each bucket is listed once, in order. Requires `-inverted-index`.
+- `-per-line-stats`: write, per input file, one "line tokens unique" row for every analyzed line to
+  `per_line_stats.txt`, counting English and Chinese words (lexical density per record).
+- `-region-regex REGEX`: analyze only the parts of each line matching REGEX, e.g. the message of
+  log lines or quoted speech. If REGEX has a capturing group, the first group of each match is
+  analyzed (`"([^"]*)"` analyzes the text inside double quotes, `: (.*)` the text after the first
+  ": "), otherwise the whole match. Every match is tokenized on its own, so no word or phrase spans
+  two regions; lines without a match contribute no terms. `-line-frequency` and `-section-regex`
+  still see whole lines.
+- `-section-regex REGEX`: treat each line matching REGEX (e.g. `^\d{4}-\d{2}-\d{2}` for dated
+  entries) as the header of a new section and write a term × section matrix to `sections.txt`: a
+  tab-separated header row of the section titles (the header lines), then one row of per-section
+  counts for each English and Chinese word, most frequent overall first. Headers' own words are
+  not counted in the matrix; words before the first header form a "(start)" section. Sections
+  continue across input files, for tracking terms over time.
+- `-top N`: print the N most frequent Chinese characters and English words with their counts to the
+  console, laid out in `-columns` aligned columns (widths account for double-width CJK characters).
+- `-tail N`: print the N least frequent Chinese characters and English words (after
+  `-suppress-below`), rarest first with equal counts alphabetical, for spotting rare or misspelled
+  vocabulary. Laid out like `-top`.
+- `-query TERMS`: after the analysis, print the count and frequency rank of each comma-separated
+  term in its category (Chinese character/word by script, English phrase if it contains spaces,
+  otherwise English word), or "not found". Terms with equal counts share a rank.
+- `-word-bands`: for language learners, write `word_bands.txt` with one "word count band" line
+  per English word, most frequent first, where band comes from the word's rank in a reference
+  frequency list: `common` for the top 2000, `uncommon` for ranks 2001-5000 and `rare` below that
+  or when not listed. A list of about 4900 common English words, roughly most frequent first, is
+  bundled into the program; the list is read once, after the scan.
+- `-reference-list FILE`: use FILE instead of the bundled `-word-bands` list (and write
+  `word_bands.txt` even without `-word-bands`): the most frequent word first, one per line, `#`
+  comments allowed, compared case-insensitively.
+- `-seed-terms FILE`: track a list of terms of interest (one per line) and write a focused report to
+  `seed_terms.txt`: each term's count and rank, as with `-query`, and for English and Chinese words
+  the 10 words most often found within the `-cooccurrence` window (5 tokens if unset) around it.
+- `-serve ADDR`: run an HTTP server instead of analyzing a file. `POST /analyze` takes plain text
+  (or a multipart upload in the `file` field, up to `-max-request-bytes`) and returns JSON of the form
+  `{"categories": {"english_words": [{"term": "the", "count": 3}, ...], ...}}`; `GET /health` returns "ok".
+- `-merge-json FILE...`: instead of analyzing text, combine JSON results files of earlier runs,
+  shaped like the `-serve` responses (e.g. one per node of a distributed run), into
+  `merged_results.json` in the output directory (the current one by default): per category,
+  the counts of equal terms are summed and the entries sorted by frequency. Every file must be
+  one `{"categories": {...}}` object with no other fields, whose entries each have a non-empty
+  `term` and a positive `count`; otherwise nothing is written. Terms are taken as they are, so
+  results hashed with `-hash-terms` merge as long as they used the same `-salt`.
+- `-template FILE`: render the results with a Go `text/template` (see https://pkg.go.dev/text/template),
+  e.g. to produce a Markdown table, into the output directory under the template's name without its
+  `.tmpl` suffix (`report.md.tmpl` becomes `report.md`). The template sees:
+  - `.Language`: the analyzed language (`zh`, `en` or `both`, as detected with `-lang auto`).
+  - `.Files`: the input files, each with `.Path`, `.Lines`, `.FirstLine` and `.LastLine`.
+  - `.Categories`: the deduplicated entries by category, `chinese_characters`, `chinese_words`,
+    `english_words` and `english_phrases`, each a list of entries with `.Term` and `.Count`, most
+    frequent first. For example:
+    `{{range index .Categories "english_words"}}| {{.Term}} | {{.Count}} |{{"\n"}}{{end}}`
+- `-cpuprofile FILE`, `-memprofile FILE`: write a CPU profile of the run and a heap profile taken at
+  its end, for `go tool pprof`. Both are written whenever the program returns, including after an
+  error (an interrupted `-serve` is not covered).
+
+Environment:
+Every option can also be set through an environment variable named `TXTFREQ_` followed by the
+option name in upper case with dashes replaced by underscores, e.g. `TXTFREQ_INPUT`,
+`TXTFREQ_OUTDIR` or `TXTFREQ_COOCCURRENCE_MIN`. Command-line flags take precedence over
+environment variables, which take precedence over the built-in defaults.
 */
 
+// Command-line options
+var (
+	inputPath = flag.String("input", "", "input file or glob pattern to analyze (default: choose via dialog)")
+	outDir    = flag.String("outdir", "", "directory to write output files to (default: the input file's folder)")
+
+	language  = flag.String("lang", "both", "categories to analyze: auto (detect), zh, en or both")
+	weighted  = flag.Bool("weighted", false, "read pre-aggregated \"text<TAB>weight\" lines; terms count weight times")
+	skipLines = flag.Int("skip-lines", 0, "skip this many header lines at the start of each input file")
+	startLine = flag.Int("start-line", 1, "first line to analyze (1-based, inclusive)")
+	endLine   = flag.Int("end-line", 0, "last line to analyze (1-based, inclusive; 0 means the last line)")
+
+	chunkSize    = flag.Int("chunk-size", 0, "analyze lines longer than this many bytes in chunks (0 reads whole lines)")
+	chunkOverlap = flag.Int("chunk-overlap", 1024, "bytes at the end of each -chunk-size chunk searched for a boundary that splits no term")
+
+	outputFormat     = flag.String("format", "txt", "format of deduplicated outputs: txt, parquet, go or msgpack; graphml for the -cooccurrence graph")
+	toStdout         = flag.Bool("stdout", false, "write the deduplicated results to standard output instead of files, for pipelines")
+	flushInterval    = flag.String("flush-interval", "", "periodically write frequency snapshots, every N lines or a duration like 30s")
+	external         = flag.Bool("external", false, "count with bounded memory by spilling to disk (core outputs only)")
+	externalMaxTerms = flag.Int("external-max-terms", 1000000, "distinct terms per category held in memory with -external")
+	workers          = flag.Int("workers", 1, "number of input files analyzed concurrently")
+	ioThreads        = flag.Int("threads-io", 0, "analyze lines in N goroutines while another reads the input (0 reads and analyzes in turn)")
+	ioBuffer         = flag.Int("io-buffer", 4096, "lines read ahead of the analysis with -threads-io")
+	checkpointEvery  = flag.Int("checkpoint-every", 0, "save a resumable checkpoint every N analyzed lines (0 disables)")
+	resume           = flag.Bool("resume", false, "continue an interrupted run from its checkpoint")
+	listCategories   = flag.Bool("list-categories", false, "print the token categories with a short description and exit")
+	listFormats      = flag.Bool("list-formats", false, "print the -format output formats with a short description and exit")
+	validateCounts   = flag.Bool("validate", false, "check that each category's counts sum to the length of its duplicated list")
+	mergeJSON        = flag.Bool("merge-json", false, "sum the counts of the JSON results files given as arguments into merged_results.json")
+	inspect          = flag.Bool("inspect", false, "report the detected encoding, language and script composition of the inputs and exit")
+	wordCount        = flag.Bool("wc", false, "print line, word, character and byte counts of the inputs like wc and exit")
+	retries          = flag.Int("retries", 0, "retry opening and reading an input file up to N times on transient errors, with backoff")
+	showProgress     = flag.Bool("progress", false, "show reading progress and an ETA for the input files")
+	compressOutput   = flag.Bool("compress", false, "gzip-compress output files (adds a .gz suffix)")
+	filterCmd        = flag.String("filter-cmd", "", "external command transforming English and Chinese words, one per line")
+	surfaceSpelling  = flag.Bool("surface-forms", false, "write deduplicated English words in their most frequent spelling, e.g. iPhone, instead of lower-cased")
+	dupLowercase     = flag.Bool("dedupe-case-insensitive", false, "lower-case the duplicated English list like the frequency counts")
+	minWordLength    = flag.Int("min-word-length", 1, "omit English words shorter than this many characters from the deduplicated output")
+	maxTermLength    = flag.Int("max-term-length", 256, "discard words and phrases longer than this many characters (0 means no limit)")
+	stripChars       = flag.String("strip-chars", "", "characters to remove from each line before tokenizing, e.g. \"·_\"")
+	stripInvisible   = flag.Bool("strip-invisible", true, "remove zero-width characters and soft hyphens before tokenizing (-strip-invisible=false keeps them)")
+	foldLigatures    = flag.Bool("normalize-ligatures", false, "decompose ligatures (NFKC) and map smart quotes and dashes to ASCII before tokenizing")
+	diacriticFold    = flag.Bool("fold-diacritics", false, "count English words and phrases with accents removed, e.g. café as cafe")
+	homoglyphMerge   = flag.Bool("merge-homoglyphs", false, "map look-alike Cyrillic and Greek letters to Latin in words that mix them with Latin letters")
+	chineseNumbers   = flag.Bool("normalize-chinese-numbers", false, "convert Chinese numbers like 三十五 to Arabic digits before counting")
+	normalizeDigits  = flag.Bool("normalize-digits", false, "replace each run of digits with the token <NUM> before counting")
+	trimPunctuation  = flag.Bool("trim-punctuation", false, "trim punctuation such as ' and - from both ends of English words and phrases")
+	normalizeSpace   = flag.Bool("normalize-whitespace", false, "collapse runs of whitespace in English phrases to a single space")
+	mergeVariants    = flag.Bool("merge-whitespace-variants", false, "count English phrases differing only in hyphens, whitespace or other punctuation between words as one phrase")
+	chineseCharRe    = flag.String("chinese-char-regex", "", "regex matching Chinese characters, replacing the built-in one")
+	chineseWordRe    = flag.String("chinese-word-regex", "", "regex matching Chinese words, replacing the built-in one")
+	englishWordRe    = flag.String("english-word-regex", "", "regex matching English words, replacing the built-in one")
+	englishPhraseRe  = flag.String("english-phrase-regex", "", "regex matching English phrases, replacing the built-in one")
+	tokenizer        = flag.String("tokenizer", "regex", "English word tokenization: regex, or unicode for Unicode word boundaries")
+	splitIdentifiers = flag.Bool("split-identifiers", false, "count the words of camelCase and snake_case identifiers as English words")
+	splitHyphens     = flag.Bool("split-hyphens", false, "treat hyphens as English word separators")
+	chineseCombined  = flag.Bool("chinese-combined", false, "write each Chinese character with the words it appears in to chinese_combined.txt")
+	requireLetter    = flag.Bool("require-letter", false, "drop words and phrases without any letter or Han character, e.g. \"''\" or \"2024\"")
+	dedupeSubphrases = flag.Bool("dedupe-subphrases", false, "drop English phrases counted no more often than a longer phrase containing them")
+	byCount          = flag.Bool("by-count", false, "also write deduplicated outputs grouped by shared count (*_by_count.txt)")
+	shard            = flag.Bool("shard", false, "split deduplicated outputs into one file per initial character, e.g. deduplicated_english_a.txt")
+	bandSpec         = flag.String("band", "", "split deduplicated outputs by frequency band boundaries, e.g. 100,10")
+	groupInitial     = flag.Bool("group-by-initial", false, "organize deduplicated text outputs into sections by initial letter/character")
+	collateLocale    = flag.String("collate", "", "locale for alphabetical sorting, e.g. fr, de or root (default: byte order)")
+	groupOrder       = flag.String("group-order", "freq", "order of terms within -group-by-initial sections: freq or alpha")
+	scriptName       = flag.String("script", "", "also count words of this Unicode script, e.g. Cyrillic or Greek (script_<name>.txt)")
+	mixedScript      = flag.Bool("mixed-script", false, "report tokens mixing Han and Latin letters in mixed_script.txt")
+	lineFrequency    = flag.Bool("line-frequency", false, "also count identical whole lines (line_frequency.txt)")
+	social           = flag.Bool("social", false, "count #hashtags and @mentions separately (hashtags.txt, mentions.txt)")
+	urlsEmails       = flag.Bool("urls", false, "also write the URLs and email addresses found to urls.txt and emails.txt")
+	romanNumerals    = flag.Bool("roman-numerals", false, "count upper-case Roman numerals like XIV separately (roman_numerals.txt)")
+	dictionaryPath   = flag.String("dictionary", "", "wordlist file; keep only listed terms in deduplicated outputs")
+
+	roundStep     = flag.Int("round-counts", 0, "round every count to the nearest multiple of N for publishing")
+	suppressBelow = flag.Int("suppress-below", 0, "omit terms whose count is below N for publishing")
+	hashTerms     = flag.Bool("hash-terms", false, "replace output terms with a stable hash")
+	hashSalt      = flag.String("salt", "", "salt prepended to terms before hashing (used with -hash-terms)")
+
+	cooccurrenceWindow = flag.Int("cooccurrence", 0, "count English word pairs within a window of N tokens (0 disables)")
+	cooccurrenceMin    = flag.Int("cooccurrence-min", 2, "minimum count for a co-occurrence pair to be kept")
+
+	mutualInformation    = flag.Bool("mutual-information", false, "score English bigrams by pointwise mutual information (mutual_information.txt)")
+	mutualInformationMin = flag.Int("mutual-information-min", 3, "minimum count for a bigram to be scored by -mutual-information")
+
+	cpuProfile = flag.String("cpuprofile", "", "write a CPU profile (pprof format) to this file")
+	memProfile = flag.String("memprofile", "", "write a heap profile (pprof format) to this file on exit")
+
+	serveAddr       = flag.String("serve", "", "run an HTTP server on this address (e.g. :8080) instead of analyzing a file")
+	maxRequestBytes = flag.Int64("max-request-bytes", 10<<20, "maximum request body size accepted by -serve")
+
+	topN    = flag.Int("top", 0, "print the N most frequent Chinese characters and English words to the console")
+	columns = flag.Int("columns", 1, "number of aligned columns for the -top and -tail console previews")
+	tailN   = flag.Int("tail", 0, "print the N least frequent Chinese characters and English words to the console")
+
+	vocab       = flag.Bool("vocab", false, "write a frequency-ordered vocabulary of English and Chinese words to vocab.txt")
+	tfidf       = flag.Bool("tfidf", false, "write per-document TF-IDF weights of English and Chinese words to tfidf.txt")
+	docFreq     = flag.Bool("document-frequency", false, "write the number of input files each English and Chinese word occurs in to document_frequency.txt")
+	longestN    = flag.Int("longest", 0, "write the N longest distinct terms per category to longest.txt")
+	byteLengths = flag.Bool("byte-lengths", false, "also report UTF-8 byte lengths next to character lengths")
+
+	templatePath    = flag.String("template", "", "render the results with this Go text/template file")
+	wordBands       = flag.Bool("word-bands", false, "tag English words as common, uncommon or rare in word_bands.txt by a bundled frequency list")
+	referenceList   = flag.String("reference-list", "", "frequency-ordered English wordlist replacing the bundled -word-bands list")
+	seedTermsPath   = flag.String("seed-terms", "", "file of terms to track (one per line); report written to seed_terms.txt")
+	sectionRegex    = flag.String("section-regex", "", "lines matching this regex start a new section; term counts per section go to sections.txt")
+	regionRegex     = flag.String("region-regex", "", "analyze only the parts of each line matching this regex (its first group if it has one)")
+	kwicN           = flag.Int("kwic", 0, "write the lines where each of the N most frequent terms occurs to kwic.txt")
+	invertedIndex   = flag.Bool("inverted-index", false, "write a JSON index of the line numbers of each term to inverted_index.json")
+	positionBuckets = flag.Int("position-buckets", 0, "in the inverted index, replace line numbers with which of N equal parts of the file they fall in")
+	perLineStats    = flag.Bool("per-line-stats", false, "write per-line token and unique token counts to per_line_stats.txt")
+	clusterDistance = flag.Int("cluster-distance", 0, "cluster English words within this edit distance into clusters.txt (0 disables)")
+)
+
+// List options
+var (
+	excludePatterns stringList // Patterns given with -exclude
+	queryTerms      stringList // Terms given with -query
+)
+
+func init() {
+	flag.Var(&excludePatterns, "exclude", "glob pattern of input files to skip (repeatable or comma-separated)")
+	flag.Var(&queryTerms, "query", "terms to report the frequency and rank of (repeatable or comma-separated)")
+}
+
 func main() {
-	// Allow users to specify the input file
-	fmt.Println("Select the input file:")
-	inputFile, err := dialog.File().
-		Title("Select Input File").
-		Filter("Text Files (*.txt)", "txt").
-		Load()
+	flag.Parse()
+	if err := applyEnv(flag.CommandLine); err != nil {
+		fmt.Printf("Error reading environment configuration: %v\n", err)
+		return
+	}
+	if err := validateFlags(); err != nil {
+		fmt.Printf("Error validating options: %v\n", err)
+		return
+	}
+
+	// Keep standard output for the results in -stdout mode; messages go to standard error
+	if *toStdout {
+		resultsOut, os.Stdout = os.Stdout, os.Stderr
+	}
+
+	// Describe what is supported and exit
+	if *listCategories || *listFormats {
+		if *listCategories {
+			printCatalog(categoryCatalog)
+		}
+		if *listFormats {
+			printCatalog(formatCatalog)
+		}
+		return
+	}
+
+	// Profile the run; the profiles are written when main returns, whichever path it takes
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
 	if err != nil {
-		fmt.Printf("Error selecting input file: %v\n", err)
+		fmt.Printf("Error starting CPU profile: %v\n", err)
 		return
 	}
-	if inputFile == "" {
-		fmt.Println("No input file selected.")
+	defer stopProfiling()
+
+	// Select the category sets to analyze
+	if err := setLanguage(*language); err != nil {
+		fmt.Printf("Invalid -lang: %v\n", err)
+		return
+	}
+
+	// Run as an HTTP service instead of analyzing a file
+	if *serveAddr != "" {
+		if err := serve(*serveAddr); err != nil {
+			fmt.Printf("Error running server: %v\n", err)
+		}
 		return
 	}
+
+	// Merge the JSON results of earlier runs instead of analyzing a file
+	if *mergeJSON {
+		mergedFile := filepath.Join(*outDir, "merged_results.json")
+		if err := writeMergedJSON(flag.Args(), mergedFile); err != nil {
+			fmt.Printf("Error merging JSON results: %v\n", err)
+			return
+		}
+		fmt.Printf("Merged %d result files into %s\n", flag.NArg(), mergedFile)
+		return
+	}
+
+	// Allow users to specify the input file. A positional argument is taken as the input,
+	// which is how a file dragged onto the executable arrives.
+	inputFile := *inputPath
+	if inputFile == "" && flag.NArg() > 0 {
+		inputFile = flag.Arg(0)
+		if flag.NArg() > 1 {
+			fmt.Printf("Only the first input argument is analyzed; ignoring %d more\n", flag.NArg()-1)
+		}
+	}
+	if inputFile == "" {
+		fmt.Println("Select the input file:")
+		var err error
+		inputFile, err = dialog.File().
+			Title("Select Input File").
+			Filter("Text Files (*.txt)", "txt").
+			Load()
+		if err != nil {
+			fmt.Printf("Error selecting input file: %v\n", err)
+			return
+		}
+		if inputFile == "" {
+			fmt.Println("No input file selected.")
+			return
+		}
+	}
 	fmt.Printf("Selected input file: %s\n", inputFile)
 
+	// Set up the collation of alphabetical sorting
+	if err := setCollation(*collateLocale); err != nil {
+		fmt.Printf("Invalid -collate: %v\n", err)
+		return
+	}
+
+	// Parse the frequency band boundaries
+	var bands []int
+	if *bandSpec != "" {
+		var err error
+		if bands, err = parseBands(*bandSpec); err != nil {
+			fmt.Printf("Invalid -band: %v\n", err)
+			return
+		}
+	}
+
+	// Set up the extra script category
+	if *scriptName != "" {
+		if err := setScript(*scriptName); err != nil {
+			fmt.Printf("Invalid -script: %v\n", err)
+			return
+		}
+	}
+
+	// Override the built-in category patterns
+	overrides := []struct {
+		name    string
+		pattern **regexp.Regexp
+		expr    string
+	}{
+		{"chinese-char-regex", &chineseCharPattern, *chineseCharRe},
+		{"chinese-word-regex", &chineseWordPattern, *chineseWordRe},
+		{"english-word-regex", &englishWordPattern, *englishWordRe},
+		{"english-phrase-regex", &englishPhrasePattern, *englishPhraseRe},
+	}
+	for _, o := range overrides {
+		if err := overridePattern(o.pattern, o.expr); err != nil {
+			fmt.Printf("Invalid -%s: %v\n", o.name, err)
+			return
+		}
+	}
+
+	// Compile the section header pattern
+	if *sectionRegex != "" {
+		var err error
+		if sectionPattern, err = regexp.Compile(*sectionRegex); err != nil {
+			fmt.Printf("Invalid -section-regex: %v\n", err)
+			return
+		}
+	}
+
+	// Compile the pattern of the regions to analyze
+	if *regionRegex != "" {
+		var err error
+		if regionPattern, err = regexp.Compile(*regionRegex); err != nil {
+			fmt.Printf("Invalid -region-regex: %v\n", err)
+			return
+		}
+	}
+
+	// Only diagnose how the inputs would be read, or count them like wc, without writing anything
+	if *inspect || *wordCount {
+		inputFiles, _, err := expandInput(inputFile)
+		if err != nil {
+			fmt.Printf("Error resolving input: %v\n", err)
+			return
+		}
+		if *inspect {
+			if err := inspectFiles(inputFiles); err != nil {
+				fmt.Printf("Error inspecting input: %v\n", err)
+			}
+			return
+		}
+		if err := printWordCounts(inputFiles); err != nil {
+			fmt.Printf("Error counting input: %v\n", err)
+		}
+		return
+	}
+
+	// Write outputs next to the input by default, not to the (often unexpected) working directory
+	if *outDir == "" {
+		*outDir = globBaseDir(inputFile)
+	}
+	fmt.Printf("Output directory: %s\n", *outDir)
+
+	// Create the output directory if needed
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			fmt.Printf("Error creating output directory %s: %v\n", *outDir, err)
+			return
+		}
+	}
+
 	// Predefined output files
-	chineseFileDedup := "deduplicated_chinese.txt"
-	chineseFileDup := "duplicated_chinese.txt"
-	englishFileDedup := "deduplicated_english.txt"
-	englishFileDup := "duplicated_english.txt"
+	chineseFileDedup := filepath.Join(*outDir, "deduplicated_chinese.txt")
+	chineseFileDup := filepath.Join(*outDir, "duplicated_chinese.txt")
+	englishFileDedup := filepath.Join(*outDir, "deduplicated_english.txt")
+	englishFileDup := filepath.Join(*outDir, "duplicated_english.txt")
+	urlsFile := filepath.Join(*outDir, "urls.txt")
+	emailsFile := filepath.Join(*outDir, "emails.txt")
+	hashtagsFile := filepath.Join(*outDir, "hashtags.txt")
+	romanFile := filepath.Join(*outDir, "roman_numerals.txt")
+	mentionsFile := filepath.Join(*outDir, "mentions.txt")
+	linesFile := filepath.Join(*outDir, "line_frequency.txt")
+	mixedScriptFile := filepath.Join(*outDir, "mixed_script.txt")
+	cooccurrenceFile := filepath.Join(*outDir, "cooccurrence.txt")
+	pmiFile := filepath.Join(*outDir, "mutual_information.txt")
+	tfidfFile := filepath.Join(*outDir, "tfidf.txt")
+	docFreqFile := filepath.Join(*outDir, "document_frequency.txt")
+	longestFile := filepath.Join(*outDir, "longest.txt")
+	clustersFile := filepath.Join(*outDir, "clusters.txt")
+	perLineStatsFile := filepath.Join(*outDir, "per_line_stats.txt")
+	sectionsFile := filepath.Join(*outDir, "sections.txt")
+	chineseCombinedFile := filepath.Join(*outDir, "chinese_combined.txt")
+	vocabFile := filepath.Join(*outDir, "vocab.txt")
+	seedTermsFile := filepath.Join(*outDir, "seed_terms.txt")
+	wordBandsFile := filepath.Join(*outDir, "word_bands.txt")
+	kwicFile := filepath.Join(*outDir, "kwic.txt")
+	indexFile := filepath.Join(*outDir, "inverted_index.json")
 
-	// Open the input file
-	file, err := os.Open(inputFile)
+	// Resolve the input files; a glob pattern may match several files, which are aggregated
+	inputFiles, excluded, err := expandInput(inputFile)
 	if err != nil {
-		fmt.Printf("Error opening input file: %v\n", err)
+		fmt.Printf("Error resolving input: %v\n", err)
 		return
 	}
-	defer file.Close()
+	if excluded > 0 {
+		fmt.Printf("Excluded %d input files\n", excluded)
+	}
+	if len(inputFiles) > 1 {
+		fmt.Printf("Matched %d input files\n", len(inputFiles))
+	}
 
-	// Regex patterns
-	chineseCharacterRegex := `[\p{Han}]`                         // Matches individual Chinese characters
-	chineseWordsRegex := `[\p{Han}]+`                            // Matches sequences of Chinese characters as words
-	englishWordRegex := `\b[a-zA-Z0-9']+(?:-[a-zA-Z0-9']+)?\b`   // Matches English words and compounds like "micro-video", also handle "I'll"
-	englishPhrasesRegex := `\b[a-zA-Z0-9][\w\s'-]*[a-zA-Z0-9]\b` // Matches English phrases with spaces
+	// Detect the language to skip irrelevant categories
+	detectedLanguage := *language
+	if *language == "auto" {
+		if detectedLanguage, err = detectLanguage(inputFiles); err != nil {
+			fmt.Printf("Error detecting language: %v\n", err)
+			return
+		}
+		setLanguage(detectedLanguage)
+	}
 
-	// Frequency maps
-	chineseCharFreq := make(map[string]int)
-	chineseWordsFreq := make(map[string]int)
-	englishWordFreq := make(map[string]int)
-	englishPhrasesFreq := make(map[string]int)
+	// Count with bounded memory, producing only the core outputs
+	if *external {
+		if err := runExternal(inputFiles, *externalMaxTerms, chineseFileDedup, chineseFileDup, englishFileDedup, englishFileDup); err != nil {
+			fmt.Printf("Error in external mode: %v\n", err)
+		}
+		return
+	}
 
-	// Lists to retain duplications (as they appear in the original order)
-	chineseCharList := []string{}
-	chineseWordsList := []string{}
-	englishWordList := []string{}
-	englishPhrasesList := []string{}
+	// Analyze every input file
+	a := newAnalysis()
+	if *showProgress {
+		var total int64
+		for _, path := range inputFiles {
+			if info, err := os.Stat(path); err == nil {
+				total += info.Size()
+			}
+		}
+		a.progress = newProgress(total)
+	}
+	if *flushInterval != "" {
+		if a.flush, err = newFlusher(*flushInterval, func() {
+			writeSnapshot(a, chineseFileDedup, englishFileDedup)
+		}); err != nil {
+			fmt.Printf("Invalid -flush-interval: %v\n", err)
+			return
+		}
+	}
+	checkpointFile := filepath.Join(*outDir, checkpointFileName)
+	if *checkpointEvery > 0 || *resume {
+		if a.checkpoint, err = newCheckpointer(checkpointFile, *checkpointEvery, inputFiles); err != nil {
+			fmt.Printf("Error hashing input files: %v\n", err)
+			return
+		}
+	}
+	if *resume {
+		data, err := loadCheckpoint(checkpointFile, inputFiles, a.checkpoint.hashes)
+		if err != nil {
+			fmt.Printf("Error resuming from %s: %v\n", checkpointFile, err)
+			return
+		}
+		a.importState(data.State)
+		a.resumeFrom = data
+		fmt.Printf("Resuming %s at byte %d\n", data.Partial.Path, data.Offset)
+	}
+	if *checkpointEvery <= 0 {
+		a.checkpoint = nil
+	}
+	if *workers > 1 && len(inputFiles) > 1 {
+		if path, err := a.scanFilesConcurrently(inputFiles, *workers); err != nil {
+			fmt.Printf("Error reading input file %s: %v\n", path, err)
+			return
+		}
+	} else {
+		for _, path := range inputFiles[len(a.files):] {
+			if err := a.scanFile(path); err != nil {
+				fmt.Printf("Error reading input file %s: %v\n", path, err)
+				return
+			}
+		}
+	}
 
-	// Read the input file line by line
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	// The run completed, so its checkpoint is no longer needed
+	if *checkpointEvery > 0 || *resume {
+		os.Remove(checkpointFile)
+	}
+	if a.progress != nil {
+		a.progress.finish()
+	}
 
-		// Match and process Chinese characters
-		chineseCharMatches := regexp.MustCompile(chineseCharacterRegex).FindAllString(line, -1)
-		for _, char := range chineseCharMatches {
-			chineseCharFreq[char]++
-			chineseCharList = append(chineseCharList, char) // Append in original order
+	// Transform the words with the external filter command
+	if *filterCmd != "" {
+		if err := a.applyFilterCommand(*filterCmd); err != nil {
+			fmt.Printf("Error running filter command: %v\n", err)
+			return
 		}
+	}
 
-		// Match and process Chinese words
-		chineseWordMatches := regexp.MustCompile(chineseWordsRegex).FindAllString(line, -1)
-		for _, word := range chineseWordMatches {
-			chineseWordsFreq[word]++
-			chineseWordsList = append(chineseWordsList, word) // Append in original order
-		}
+	// Check that the two counting paths agree, before any option removes terms
+	if *validateCounts && !a.checkCounts() {
+		fmt.Println("Warning: counts and duplicated lists disagree; please report the input that caused it.")
+	}
 
-		// Match and process English words (with hyphenated compounds like "micro-video")
-		englishWordMatches := regexp.MustCompile(englishWordRegex).FindAllString(line, -1)
-		for _, word := range englishWordMatches {
-			normalizedWord := strings.ToLower(word) // Normalize to lowercase for consistency
-			englishWordFreq[normalizedWord]++
-			englishWordList = append(englishWordList, word) // Append in original order
+	// Warn about empty categories, which usually point to a wrong file or encoding
+	if runChinese && len(a.chineseCharFreq) == 0 {
+		fmt.Println("Warning: no Chinese characters found.")
+	}
+	if runEnglish && len(a.englishWordFreq) == 0 {
+		fmt.Println("Warning: no English words found.")
+	}
+	if len(a.chineseCharFreq) == 0 && len(a.englishWordFreq) == 0 {
+		fmt.Println("Warning: the input contains no Chinese or English text; check the selected file and its encoding (UTF-8 is expected).")
+	}
+
+	// Drop terms made of digits, punctuation or symbols only
+	if *requireLetter {
+		if dropped := a.dropLetterless(); dropped > 0 {
+			fmt.Printf("Dropped %d terms without letters\n", dropped)
 		}
+	}
 
-		// Match and process English phrases
-		englishPhraseMatches := regexp.MustCompile(englishPhrasesRegex).FindAllString(line, -1)
-		for _, phrase := range englishPhraseMatches {
-			normalizedPhrase := strings.ToLower(strings.TrimSpace(phrase)) // Normalize case and trim
-			englishPhrasesFreq[normalizedPhrase]++
-			englishPhrasesList = append(englishPhrasesList, phrase) // Append in original order
+	// Drop phrases seen mostly as part of a longer phrase
+	if *dedupeSubphrases {
+		if removed := a.dedupeSubphrases(); removed > 0 {
+			fmt.Printf("Removed %d redundant sub-phrases\n", removed)
 		}
 	}
 
-	// Handle scanner error
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading input file: %v\n", err)
-		return
+	// Coarsen the counts before anything is published
+	if *roundStep > 1 || *suppressBelow > 0 {
+		if suppressed := a.roundCounts(*roundStep, *suppressBelow); suppressed > 0 {
+			fmt.Printf("Suppressed %d terms with low counts\n", suppressed)
+		}
 	}
 
 	// Sort lists by frequency (descending order) for deduplicated outputs
-	chineseCharDedupSorted := sortByFrequency(chineseCharFreq)
-	englishWordDedupSorted := sortByFrequency(englishWordFreq)
+	chineseCharDedupSorted := sortByFrequency(a.chineseCharFreq)
+	englishWordDedupSorted := sortByFrequency(a.englishWordFreq)
+
+	// Drop English words shorter than -min-word-length, such as stray OCR letters
+	if *minWordLength > 1 {
+		englishWordDedupSorted = filterShortTerms(englishWordDedupSorted, *minWordLength)
+	}
+
+	// Keep only dictionary terms in the deduplicated outputs
+	if *dictionaryPath != "" {
+		dictionary, err := loadWordSet(*dictionaryPath)
+		if err != nil {
+			fmt.Printf("Error reading dictionary %s: %v\n", *dictionaryPath, err)
+			return
+		}
+		chineseCharDedupSorted = filterDictionary(chineseCharDedupSorted, dictionary)
+		englishWordDedupSorted = filterDictionary(englishWordDedupSorted, dictionary)
+	}
+
+	// Report the English words under their most frequent spelling
+	englishDedupFreq := a.englishWordFreq
+	if *surfaceSpelling {
+		englishWordDedupSorted, englishDedupFreq = a.surfaceForms(englishWordDedupSorted, a.englishWordFreq)
+	}
+
+	// Write the deduplicated results to standard output instead of the core output files
+	if *toStdout {
+		var categories []stdoutCategory
+		if runChinese {
+			categories = append(categories, stdoutCategory{"chinese_characters", chineseCharDedupSorted, a.chineseCharFreq})
+		}
+		if runEnglish {
+			categories = append(categories, stdoutCategory{"english_words", englishWordDedupSorted, englishDedupFreq})
+		}
+		if err := writeStdout(categories); err != nil {
+			fmt.Printf("Error writing to standard output: %v\n", err)
+			return
+		}
+	}
 
 	// Write output files
-	writeToFile(chineseFileDedup, chineseCharDedupSorted) // Deduplicated Chinese characters
-	writeToFile(chineseFileDup, chineseCharList)          // Duplicated Chinese characters (original order)
-	writeToFile(englishFileDedup, englishWordDedupSorted) // Deduplicated English words
-	writeToFile(englishFileDup, englishWordList)          // Duplicated English words (original order)
+	writeDedup := writeDeduplicated
+	if *shard {
+		writeDedup = writeShards // One file per initial character
+	}
+	if runChinese && !*toStdout {
+		writeDedup(chineseFileDedup, chineseCharDedupSorted, a.chineseCharFreq) // Deduplicated Chinese characters
+		writeToFile(chineseFileDup, outputTerms(a.chineseCharList))             // Duplicated Chinese characters (original order)
+	}
+	if runEnglish && !*toStdout {
+		writeDedup(englishFileDedup, englishWordDedupSorted, englishDedupFreq)         // Deduplicated English words
+		writeToFile(englishFileDup, outputTerms(duplicatedEnglish(a.englishWordList))) // Duplicated English words (original order)
+	}
+
+	// URLs and emails, counted separately from the English words
+	if *urlsEmails {
+		writeDeduplicated(urlsFile, sortByFrequency(a.urlFreq), a.urlFreq)
+		writeDeduplicated(emailsFile, sortByFrequency(a.emailFreq), a.emailFreq)
+	}
+
+	// Hashtags and mentions of social media text
+	if *social {
+		writeDeduplicated(hashtagsFile, sortByFrequency(a.hashtagFreq), a.hashtagFreq)
+		writeDeduplicated(mentionsFile, sortByFrequency(a.mentionFreq), a.mentionFreq)
+	}
+
+	// Roman numerals, counted separately from the English words
+	if *romanNumerals {
+		writeDeduplicated(romanFile, sortByFrequency(a.romanFreq), a.romanFreq)
+	}
+
+	// Words of the user-selected script
+	if scriptPattern != nil {
+		scriptFile := filepath.Join(*outDir, "script_"+*scriptName+".txt")
+		writeDeduplicated(scriptFile, sortByFrequency(a.scriptFreq), a.scriptFreq)
+	}
+
+	// Tokens mixing Han and Latin letters, which fall between the categories
+	if *mixedScript {
+		writeToFile(mixedScriptFile, formatCounts(sortByFrequency(a.mixedFreq), a.mixedFreq))
+	}
+
+	// Whole lines, most frequent first, independent of word tokenization
+	if *lineFrequency {
+		writeToFile(linesFile, formatCounts(sortByFrequency(a.lineFreq), a.lineFreq))
+	}
+
+	// Link each Chinese character to the words it appears in
+	if *chineseCombined && runChinese {
+		writeToFile(chineseCombinedFile, formatChineseCombined(a))
+	}
+
+	// Group the deduplicated outputs by shared count
+	if *byCount && runChinese {
+		byCountFile := strings.TrimSuffix(chineseFileDedup, ".txt") + "_by_count.txt"
+		writeToFile(byCountFile, formatByCount(chineseCharDedupSorted, a.chineseCharFreq))
+	}
+	if *byCount && runEnglish {
+		byCountFile := strings.TrimSuffix(englishFileDedup, ".txt") + "_by_count.txt"
+		writeToFile(byCountFile, formatByCount(englishWordDedupSorted, englishDedupFreq))
+	}
+
+	// Split the deduplicated outputs by frequency band
+	if bands != nil && runChinese {
+		writeBands(chineseFileDedup, chineseCharDedupSorted, a.chineseCharFreq, bands)
+	}
+	if bands != nil && runEnglish {
+		writeBands(englishFileDedup, englishWordDedupSorted, englishDedupFreq, bands)
+	}
+
+	// Count and write co-occurring English word pairs
+	if *cooccurrenceWindow > 0 {
+		pairFreq := countCooccurrences(a, *cooccurrenceWindow, *cooccurrenceMin)
+		if *outputFormat == "graphml" {
+			writeToFile(strings.TrimSuffix(cooccurrenceFile, ".txt")+".graphml", formatGraphML(pairFreq, a.englishWordFreq))
+		} else {
+			writeToFile(cooccurrenceFile, formatCooccurrences(pairFreq))
+		}
+	}
+
+	// Score adjacent English word pairs by pointwise mutual information
+	if *mutualInformation {
+		writeToFile(pmiFile, formatPMI(computePMI(a, *mutualInformationMin)))
+	}
+
+	// Write the vocabulary for ML pipelines
+	if *vocab {
+		writeToFile(vocabFile, formatVocab(a))
+	}
+
+	// Weight each document's words by TF-IDF across the input set
+	if *tfidf {
+		if len(a.files) < 2 {
+			fmt.Println("Warning: TF-IDF weights are only meaningful with several input files.")
+		}
+		writeToFile(tfidfFile, formatTFIDF(a, computeTFIDF(a)))
+	}
+
+	// Count the input files each word occurs in
+	if *docFreq {
+		if len(a.files) < 2 {
+			fmt.Println("Warning: document frequencies are only meaningful with several input files.")
+		}
+		writeToFile(docFreqFile, formatDocumentFrequency(a))
+	}
+
+	// Write the longest terms of each category
+	if *longestN > 0 {
+		writeToFile(longestFile, formatLongest(a, *longestN))
+	}
+
+	// Cluster near-duplicate English words
+	if *clusterDistance > 0 {
+		writeToFile(clustersFile, formatClusters(clusterTerms(a.englishWordFreq, *clusterDistance), a.englishWordFreq))
+	}
+
+	// Write the keyword-in-context concordance of the top terms
+	if *kwicN > 0 {
+		lines, err := formatKwic(a, *kwicN)
+		if err != nil {
+			fmt.Printf("Error reading input lines for -kwic: %v\n", err)
+			return
+		}
+		writeToFile(kwicFile, lines)
+	}
+
+	// Write the inverted index of the terms' lines
+	if *invertedIndex {
+		data, err := formatInvertedIndex(a)
+		if err != nil {
+			fmt.Printf("Error encoding inverted index: %v\n", err)
+			return
+		}
+		writeToFile(indexFile, []string{string(data)})
+	}
+
+	// Write the per-line statistics
+	if *perLineStats {
+		var lines []string
+		for _, f := range a.files {
+			lines = append(lines, fmt.Sprintf("== %s ==", f.Path))
+			for _, ls := range f.LineStats {
+				lines = append(lines, fmt.Sprintf("%d %d %d", ls.Line, ls.Tokens, ls.Unique))
+			}
+		}
+		writeToFile(perLineStatsFile, lines)
+	}
+
+	// Write the term × section count matrix
+	if sectionPattern != nil {
+		writeToFile(sectionsFile, formatSectionMatrix(a))
+	}
+
+	// Write the focused report of the seed terms
+	if *seedTermsPath != "" {
+		seeds, err := loadWordList(*seedTermsPath)
+		if err != nil {
+			fmt.Printf("Error reading seed terms %s: %v\n", *seedTermsPath, err)
+			return
+		}
+		writeToFile(seedTermsFile, formatSeedReport(a, seeds))
+	}
+
+	// Tag the English words with their band in the reference frequency list
+	if *wordBands || *referenceList != "" {
+		ranks, err := loadWordRanks(*referenceList)
+		if err != nil {
+			fmt.Printf("Error reading reference list %s: %v\n", *referenceList, err)
+			return
+		}
+		writeToFile(wordBandsFile, formatWordBands(a.englishWordFreq, ranks))
+	}
+
+	// Render the results with the user's template
+	if *templatePath != "" {
+		templateFile := filepath.Join(*outDir, templateOutputName(*templatePath))
+		if err := writeTemplate(*templatePath, templateFile, a, detectedLanguage); err != nil {
+			fmt.Printf("Error rendering template %s: %v\n", *templatePath, err)
+			return
+		}
+	}
 
 	fmt.Println("All output files written successfully.")
+
+	// Print the summary report
+	fmt.Println()
+	fmt.Println("Summary:")
+	if *language == "auto" {
+		fmt.Printf("Language: %s (detected)\n", detectedLanguage)
+	} else {
+		fmt.Printf("Language: %s\n", detectedLanguage)
+	}
+	for _, f := range a.files {
+		if f.Malformed > 0 {
+			fmt.Printf("%s: malformed weighted lines skipped: %d\n", f.Path, f.Malformed)
+		}
+		if f.Skipped > 0 {
+			fmt.Printf("%s: header lines skipped: %d\n", f.Path, f.Skipped)
+		}
+		if f.FirstLine == 0 {
+			fmt.Printf("%s: lines processed: none (input has %d lines)\n", f.Path, f.Lines)
+		} else {
+			fmt.Printf("%s: lines processed: %d-%d (input has %d lines), %d English words, %d Chinese words\n", f.Path, f.FirstLine, f.LastLine, f.Lines,
+				f.EnglishWords[1]-f.EnglishWords[0], f.ChineseWords[1]-f.ChineseWords[0])
+		}
+	}
+	if a.droppedTerms > 0 {
+		fmt.Printf("Terms dropped for exceeding %d characters: %d\n", *maxTermLength, a.droppedTerms)
+	}
+	printRichness("English words", a.englishKeyList, a.englishWordFreq)
+	printRichness("Chinese words", a.chineseWordsList, a.chineseWordsFreq)
+	printReadability(a)
+
+	// Preview the most frequent terms
+	if *topN > 0 {
+		fmt.Println()
+		if runChinese {
+			printTop("Chinese characters", a.chineseCharFreq, *topN)
+		}
+		if runEnglish {
+			printTop("English words", a.englishWordFreq, *topN)
+		}
+	}
+	if *tailN > 0 {
+		fmt.Println()
+		if runChinese {
+			printTail("Chinese characters", a.chineseCharFreq, *tailN)
+		}
+		if runEnglish {
+			printTail("English words", a.englishWordFreq, *tailN)
+		}
+	}
+
+	// Look up the query terms
+	if len(queryTerms) > 0 {
+		printQueries(a, queryTerms)
+	}
+}
+
+// Function to expand an input path into the files to analyze.
+// A directory is walked recursively for .txt files, honoring its .txtfreqignore file.
+// Patterns containing glob metacharacters are expanded with filepath.Glob (so this works
+// the same on Windows, where shells don't glob); a pattern matching nothing is an error.
+// Matched files selected by -exclude, and previous output files of this program,
+// are skipped and counted in excluded.
+func expandInput(pattern string) (files []string, excluded int, err error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		if files, excluded, err = walkInputDir(pattern); err != nil {
+			return nil, excluded, err
+		}
+		if len(files) == 0 {
+			return nil, excluded, fmt.Errorf("%w: no .txt files in directory %q (%d excluded)", ErrNoInput, pattern, excluded)
+		}
+		return files, excluded, nil
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, 0, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: glob pattern %q: %v", ErrInvalidOption, pattern, err)
+	}
+
+	for _, match := range matches {
+		if info, err := os.Stat(match); err != nil || info.IsDir() {
+			continue
+		}
+		if isExcluded(match) || isOutputFile(match, globBaseDir(pattern)) {
+			excluded++
+			continue
+		}
+		files = append(files, match)
+	}
+	if len(files) == 0 {
+		return nil, excluded, fmt.Errorf("%w: nothing matches %q (%d excluded)", ErrNoInput, pattern, excluded)
+	}
+	return files, excluded, nil
+}
+
+// Name patterns of the files this program writes, so a previous run's output
+// is never read back as input
+var outputFilePatterns = []string{
+	"deduplicated_*",
+	"duplicated_*",
+	"cooccurrence.txt*",
+	"cooccurrence.graphml*",
+	"mutual_information.txt*",
+	"tfidf.txt*",
+	"document_frequency.txt*",
+	"longest.txt*",
+	"clusters.txt*",
+	"per_line_stats.txt*",
+	"kwic.txt*",
+	"inverted_index.json*",
+	"sections.txt*",
+	"chinese_combined.txt*",
+	"vocab.txt*",
+	"seed_terms.txt*",
+	"word_bands.txt*",
+	"merged_results.json*",
+	"urls.txt*",
+	"emails.txt*",
+	"hashtags.txt*",
+	"roman_numerals.txt*",
+	"mentions.txt*",
+	"line_frequency.txt*",
+	"mixed_script.txt*",
+	"script_*",
+}
+
+// Helper function to check whether a path is an output file of this program: either its
+// name matches a known output pattern or it lies inside an -outdir that differs from inputDir
+func isOutputFile(path, inputDir string) bool {
+	for _, pattern := range outputFilePatterns {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+
+	if *outDir == "" {
+		return false
+	}
+	absOut, err1 := filepath.Abs(*outDir)
+	absInput, err2 := filepath.Abs(inputDir)
+	absPath, err3 := filepath.Abs(path)
+	if err1 != nil || err2 != nil || err3 != nil || absOut == absInput {
+		// Output written next to the inputs is only recognized by name
+		return false
+	}
+	rel, err := filepath.Rel(absOut, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Helper function to get the directory part of a glob pattern before its first metacharacter,
+// e.g. "data/*/*.txt" -> "data" (for a plain path this is its directory; a directory is its own base)
+func globBaseDir(pattern string) string {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		return pattern
+	}
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// Helper function to check a path against the -exclude patterns,
+// matching either its base name or the whole path
+func isExcluded(path string) bool {
+	for _, pattern := range excludePatterns {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to write a deduplicated, frequency-sorted category in the selected -format.
+// filePath is the text output path; other formats replace its ".txt" extension.
+func writeDeduplicated(filePath string, sortedTerms []string, freqMap map[string]int) {
+	switch *outputFormat {
+	case "parquet":
+		writeParquet(strings.TrimSuffix(filePath, ".txt")+".parquet", sortedTerms, freqMap)
+	case "go":
+		writeGoSource(strings.TrimSuffix(filePath, ".txt")+".go", sortedTerms, freqMap)
+	case "msgpack":
+		writeMsgpack(strings.TrimSuffix(filePath, ".txt")+".msgpack", sortedTerms, freqMap)
+	default:
+		if *groupInitial {
+			writeToFile(filePath, groupByInitial(sortedTerms, *groupOrder == "alpha"))
+			return
+		}
+		writeToFile(filePath, outputTerms(sortedTerms))
+	}
 }
 
 // Function to write data to a file
+// (gzip-compressed with a ".gz" suffix when -compress is set). The file is replaced
+// atomically, so an interrupted run leaves the previous version intact.
 func writeToFile(filePath string, data []string) {
-	file, err := os.Create(filePath)
-	if err != nil {
-		fmt.Printf("Error creating file %s: %v\n", filePath, err)
-		return
+	if *compressOutput {
+		filePath += ".gz"
 	}
-	defer file.Close()
 
-	writer := bufio.NewWriter(file)
+	if err := writeFileAtomic(filePath, data); err != nil {
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+	}
+}
+
+// Helper function to write data one item per line, gzip-compressed when -compress is set
+func writeLines(out io.Writer, data []string) error {
+	var gz *gzip.Writer
+	if *compressOutput {
+		gz = gzip.NewWriter(out)
+		out = gz
+	}
+
+	writer := bufio.NewWriter(out)
 	for _, item := range data {
 		writer.WriteString(item + "\n")
 	}
-	writer.Flush()
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	// Closing the gzip writer flushes the remaining data and writes the stream footer
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// Helper function to replace a term with the first 8 hex chars of its salted SHA-256 hash
+func hashTerm(term string) string {
+	sum := sha256.Sum256([]byte(*hashSalt + term))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// Helper function to prepare terms for output, hashing them (in order) if -hash-terms is set
+func outputTerms(terms []string) []string {
+	if !*hashTerms {
+		return terms
+	}
+
+	hashed := make([]string, len(terms))
+	for i, term := range terms {
+		hashed[i] = hashTerm(term)
+	}
+	return hashed
 }
 
 // Helper function to sort map entries by frequency (descending order)
@@ -167,9 +1385,13 @@ func sortByFrequency(freqMap map[string]int) []string {
 		sortedPairs = append(sortedPairs, kv{k, v})
 	}
 
-	// Sort by frequency in descending order
+	// Sort by frequency in descending order, breaking ties by byte-wise key order so
+	// the output is identical across runs, platforms and system locales
 	sort.Slice(sortedPairs, func(i, j int) bool {
-		return sortedPairs[i].Value > sortedPairs[j].Value
+		if sortedPairs[i].Value != sortedPairs[j].Value {
+			return sortedPairs[i].Value > sortedPairs[j].Value
+		}
+		return sortedPairs[i].Key < sortedPairs[j].Key
 	})
 
 	// Extract sorted keys
@@ -180,3 +1402,56 @@ func sortByFrequency(freqMap map[string]int) []string {
 
 	return sortedKeys
 }
+
+// Helper function to get the duplicated English words as written: in their original casing,
+// or lower-cased like the frequency counts with -dedupe-case-insensitive
+func duplicatedEnglish(words []string) []string {
+	if !*dupLowercase {
+		return words
+	}
+	lowered := make([]string, len(words))
+	for i, word := range words {
+		lowered[i] = strings.ToLower(word)
+		if *normalizeDigits {
+			lowered[i] = strings.ReplaceAll(lowered[i], strings.ToLower(numPlaceholder), numPlaceholder) // Keep the placeholder as is
+		}
+	}
+	return lowered
+}
+
+// Helper function to keep the terms of at least minLength runes
+func filterShortTerms(terms []string, minLength int) []string {
+	var kept []string
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= minLength {
+			kept = append(kept, term)
+		}
+	}
+	return kept
+}
+
+// Function to format terms with their counts, one "count<TAB>term" per output line
+func formatCounts(sortedTerms []string, freqMap map[string]int) []string {
+	terms := outputTerms(sortedTerms)
+	lines := make([]string, len(sortedTerms))
+	for i, term := range sortedTerms {
+		lines[i] = fmt.Sprintf("%d\t%s", freqMap[term], terms[i])
+	}
+	return lines
+}
+
+// Flag value collecting a list of strings from repeated or comma-separated flags
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Function to check that sortByFrequency orders by count, breaking ties alphabetically
+func TestSortByFrequencyTies(t *testing.T) {
+	freqMap := map[string]int{"pear": 2, "apple": 5, "fig": 2, "banana": 2, "kiwi": 1, "Zebra": 2, "中": 2}
+	want := []string{"apple", "Zebra", "banana", "fig", "pear", "中", "kiwi"} // Byte order: upper case first, Han last
+	if got := sortByFrequency(freqMap); !reflect.DeepEqual(got, want) {
+		t.Errorf("sortByFrequency = %q, want %q", got, want)
+	}
+}
+
+// Function to check that sortByFrequency output is byte-for-byte identical across repeated
+// runs, although map iteration order changes between them
+func TestSortByFrequencyDeterministic(t *testing.T) {
+	// Helper function to format a freshly built map, many of its terms tied
+	format := func() string {
+		freqMap := make(map[string]int)
+		for i := 0; i < 500; i++ {
+			freqMap[fmt.Sprintf("term%03d", i)] = i % 7
+		}
+		var b strings.Builder
+		for _, term := range sortByFrequency(freqMap) {
+			fmt.Fprintf(&b, "%s %d\n", term, freqMap[term])
+		}
+		return b.String()
+	}
+
+	first := format()
+	for run := 1; run < 20; run++ {
+		if got := format(); got != first {
+			t.Fatalf("run %d output differs from the first run", run)
+		}
+	}
+}
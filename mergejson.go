@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Function to read a JSON results file, like the responses of -serve, checking its shape: an
+// object with only a "categories" object of category names to arrays of entries, each with a
+// non-empty "term" and a positive "count"
+func readJSONResult(path string) (jsonResult, error) {
+	var result jsonResult
+	file, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return result, fmt.Errorf("%w: %s: %v", ErrInvalidResults, path, err)
+	}
+	if decoder.More() {
+		return result, fmt.Errorf("%w: %s: data after the results object", ErrInvalidResults, path)
+	}
+	if result.Categories == nil {
+		return result, fmt.Errorf("%w: %s: no \"categories\" object", ErrInvalidResults, path)
+	}
+	for category, entries := range result.Categories {
+		for i, e := range entries {
+			if e.Term == "" || e.Count < 1 {
+				return result, fmt.Errorf("%w: %s: entry %d of %s needs a term and a positive count", ErrInvalidResults, path, i, category)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Function to merge JSON results files (-merge-json), e.g. of several nodes analyzing parts of a
+// corpus: the counts of each category are summed per term, and the categories are sorted by
+// frequency as in the inputs
+func mergeJSONResults(paths []string) (jsonResult, error) {
+	freq := make(map[string]map[string]int)
+	for _, path := range paths {
+		result, err := readJSONResult(path)
+		if err != nil {
+			return jsonResult{}, err
+		}
+		for category, entries := range result.Categories {
+			if freq[category] == nil {
+				freq[category] = make(map[string]int)
+			}
+			for _, e := range entries {
+				freq[category][e.Term] += e.Count
+			}
+		}
+	}
+
+	merged := jsonResult{Categories: make(map[string][]jsonEntry)}
+	for category, freqMap := range freq {
+		sorted := sortByFrequency(freqMap)
+		entries := make([]jsonEntry, len(sorted))
+		for i, term := range sorted {
+			entries[i] = jsonEntry{Term: term, Count: freqMap[term]} // Terms are already in output form
+		}
+		merged.Categories[category] = entries
+	}
+	return merged, nil
+}
+
+// Function to merge JSON results files and write the merged results to filePath
+func writeMergedJSON(paths []string, filePath string) error {
+	merged, err := mergeJSONResults(paths)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if *compressOutput {
+		filePath += ".gz"
+	}
+	return writeFileAtomic(filePath, []string{string(data)})
+}
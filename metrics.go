@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Vocabulary richness metrics derived from token (N) and type (V) counts
+type richness struct {
+	Tokens    int
+	Types     int
+	TTR       float64 // Type-token ratio: V / N
+	RootTTR   float64 // Guiraud's root TTR: V / sqrt(N)
+	HerdanC   float64 // Herdan's C: log(V) / log(N)
+	HeapsK    float64 // Heaps' law V = K * N^beta, fitted on the vocabulary growth curve
+	HeapsBeta float64
+	HeapsFit  bool // False when there are too few distinct sample points to fit Heaps' law
+}
+
+// Helper function to compute richness metrics from the tokens in original order, each of them
+// a key of freqMap
+func computeRichness(tokens []string, freqMap map[string]int) richness {
+	r := richness{Tokens: len(tokens), Types: len(freqMap)}
+	if r.Tokens == 0 {
+		return r
+	}
+
+	n := float64(r.Tokens)
+	v := float64(r.Types)
+	r.TTR = v / n
+	r.RootTTR = v / math.Sqrt(n)
+	if r.Tokens > 1 {
+		r.HerdanC = math.Log(v) / math.Log(n)
+	}
+
+	// Sample the vocabulary growth curve at geometrically spaced points so the
+	// log-log regression is not dominated by the tail of the text
+	seen := make(map[string]struct{})
+	var xs, ys []float64
+	next := 1.0
+	for i, token := range tokens {
+		seen[token] = struct{}{}
+		count := i + 1
+		if float64(count) >= next || count == len(tokens) {
+			xs = append(xs, math.Log(float64(count)))
+			ys = append(ys, math.Log(float64(len(seen))))
+			next = math.Max(next*1.1, next+1)
+		}
+	}
+	r.HeapsK, r.HeapsBeta, r.HeapsFit = fitLine(xs, ys)
+	r.HeapsK = math.Exp(r.HeapsK)
+
+	return r
+}
+
+// Helper function to fit y = a + b*x by least squares
+func fitLine(xs, ys []float64) (a, b float64, ok bool) {
+	if len(xs) < 2 {
+		return 0, 0, false
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXX += xs[i] * xs[i]
+		sumXY += xs[i] * ys[i]
+	}
+	n := float64(len(xs))
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, 0, false
+	}
+
+	b = (n*sumXY - sumX*sumY) / denom
+	a = (sumY - b*sumX) / n
+	return a, b, true
+}
+
+// Function to print the richness metrics of a category to the summary report
+func printRichness(label string, tokens []string, freqMap map[string]int) {
+	r := computeRichness(tokens, freqMap)
+	fmt.Printf("%s: %d tokens, %d types\n", label, r.Tokens, r.Types)
+	if r.Tokens == 0 {
+		return
+	}
+
+	fmt.Printf("  Type-token ratio: %.4f\n", r.TTR)
+	fmt.Printf("  Root TTR: %.4f\n", r.RootTTR)
+	if r.Tokens > 1 {
+		fmt.Printf("  Herdan's C: %.4f\n", r.HerdanC)
+	}
+	if r.HeapsFit {
+		fmt.Printf("  Heaps' law: K = %.4f, beta = %.4f\n", r.HeapsK, r.HeapsBeta)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Function to write a deduplicated output as a MessagePack array of {"term", "count"} maps,
+// the same shape as a category of the JSON results
+func writeMsgpack(filePath string, sortedTerms []string, freqMap map[string]int) {
+	file, err := createAtomic(filePath)
+	if err != nil {
+		fmt.Printf("Error creating file %s: %v\n", filePath, err)
+		return
+	}
+
+	if err := newMsgpackEncoder(file).Encode(newJSONEntries(sortedTerms, freqMap)); err != nil {
+		file.abort()
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+		return
+	}
+	if err := file.commit(); err != nil {
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+	}
+}
+
+// Helper function to create a MessagePack encoder using the keys of the JSON results
+func newMsgpackEncoder(w io.Writer) *msgpack.Encoder {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json") // Same keys as the JSON results
+	encoder.UseCompactInts(true)
+	return encoder
+}
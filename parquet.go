@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Row of a Parquet frequency file; the schema (term: string, count: int64) is kept stable
+// for downstream consumers such as Spark or pandas
+type parquetRow struct {
+	Term  string `parquet:"name=term, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Count int64  `parquet:"name=count, type=INT64"`
+}
+
+// Function to write sorted terms and their counts to a Parquet file
+func writeParquet(filePath string, sortedTerms []string, freqMap map[string]int) {
+	file, err := createAtomic(filePath)
+	if err != nil {
+		fmt.Printf("Error creating file %s: %v\n", filePath, err)
+		return
+	}
+
+	pw, err := writer.NewParquetWriterFromWriter(file, new(parquetRow), 1)
+	if err != nil {
+		file.abort()
+		fmt.Printf("Error creating Parquet writer for %s: %v\n", filePath, err)
+		return
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	terms := outputTerms(sortedTerms)
+	for i, term := range sortedTerms {
+		row := parquetRow{Term: terms[i], Count: int64(freqMap[term])}
+		if err := pw.Write(row); err != nil {
+			file.abort()
+			fmt.Printf("Error writing file %s: %v\n", filePath, err)
+			return
+		}
+	}
+
+	// WriteStop flushes the last row group and writes the file footer
+	if err := pw.WriteStop(); err != nil {
+		file.abort()
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+		return
+	}
+	if err := file.commit(); err != nil {
+		fmt.Printf("Error writing file %s: %v\n", filePath, err)
+	}
+}
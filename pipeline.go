@@ -0,0 +1,98 @@
+package main
+
+import "sync"
+
+// Lines handed to a -threads-io worker at a time, so channel operations stay cheap per line
+const ioBatchLines = 256
+
+// Lines of an input analyzed together by a -threads-io worker, tagged with their position in the
+// input by the order they are queued in
+type lineBatch struct {
+	lines   []string
+	refs    []lineRef
+	weights []int
+	done    chan *analysis // Receives the batch's counts once analyzed
+}
+
+// Pipeline separating reading from analyzing (-threads-io): the scanning goroutine queues batches
+// of lines, worker goroutines tokenize them into analyses of their own, and a merger adds those
+// to the main analysis strictly in queue order, so the duplicated lists keep the input order
+type linePipeline struct {
+	a       *analysis
+	batch   *lineBatch
+	work    chan *lineBatch // Batches waiting for a worker
+	pending chan *lineBatch // Batches in input order, waiting to be merged
+	workers sync.WaitGroup
+	merged  chan struct{} // Closed once every batch is merged
+}
+
+// Function to start a pipeline merging into a with the given number of workers, holding at most
+// buffer lines read ahead of the merge
+func newLinePipeline(a *analysis, workers, buffer int) *linePipeline {
+	batches := (buffer + ioBatchLines - 1) / ioBatchLines
+	p := &linePipeline{
+		a:       a,
+		work:    make(chan *lineBatch, batches),
+		pending: make(chan *lineBatch, batches),
+		merged:  make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go p.analyze()
+	}
+	go p.merge()
+	return p
+}
+
+// Function to queue a line with its position and weight for analysis
+func (p *linePipeline) add(line string, ref lineRef, weight int) {
+	if p.batch == nil {
+		p.batch = &lineBatch{done: make(chan *analysis, 1)}
+	}
+	p.batch.lines = append(p.batch.lines, line)
+	p.batch.refs = append(p.batch.refs, ref)
+	p.batch.weights = append(p.batch.weights, weight)
+	if len(p.batch.lines) >= ioBatchLines {
+		p.flush()
+	}
+}
+
+// Helper function to send the batch being filled to the workers and the merger
+func (p *linePipeline) flush() {
+	if p.batch == nil {
+		return
+	}
+	p.pending <- p.batch
+	p.work <- p.batch
+	p.batch = nil
+}
+
+// Function to analyze the remaining lines and wait until all counts are merged
+func (p *linePipeline) finish() {
+	p.flush()
+	close(p.work)
+	close(p.pending)
+	p.workers.Wait()
+	<-p.merged
+}
+
+// Helper function run by each worker: tokenize batches into fresh analyses
+func (p *linePipeline) analyze() {
+	defer p.workers.Done()
+	for batch := range p.work {
+		local := newAnalysis()
+		for i, line := range batch.lines {
+			local.lineAt, local.weight = batch.refs[i], batch.weights[i]
+			local.analyzeLine(line)
+		}
+		batch.done <- local
+	}
+}
+
+// Helper function run by the merger: add the analyzed batches to the main analysis in order
+func (p *linePipeline) merge() {
+	defer close(p.merged)
+	for batch := range p.pending {
+		p.a.merge(<-batch.done)
+	}
+}
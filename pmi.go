@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Function to score adjacent English word pairs (bigrams) by pointwise mutual information,
+// log2(P(xy) / (P(x) P(y))). Bigrams never span two input files. Pairs seen fewer than
+// minCount times are skipped, since PMI overrates rare pairs.
+func computePMI(a *analysis, minCount int) (scores []weightedTerm, bigramFreq map[string]int) {
+	unigramFreq := make(map[string]int)
+	bigramFreq = make(map[string]int)
+	words, bigrams := 0, 0
+	for _, f := range a.files {
+		fileWords := a.englishKeys(f.EnglishWords[0], f.EnglishWords[1])
+		for i, word := range fileWords {
+			unigramFreq[word]++
+			words++
+			if i > 0 {
+				bigramFreq[fileWords[i-1]+" "+word]++
+				bigrams++
+			}
+		}
+	}
+
+	for bigram, count := range bigramFreq {
+		if count < minCount {
+			continue
+		}
+		pair := strings.SplitN(bigram, " ", 2)
+		pXY := float64(count) / float64(bigrams)
+		pX := float64(unigramFreq[pair[0]]) / float64(words)
+		pY := float64(unigramFreq[pair[1]]) / float64(words)
+		scores = append(scores, weightedTerm{bigram, math.Log2(pXY / (pX * pY))})
+	}
+
+	// Highest PMI first, ties alphabetically
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Weight != scores[j].Weight {
+			return scores[i].Weight > scores[j].Weight
+		}
+		return scores[i].Term < scores[j].Term
+	})
+	return scores, bigramFreq
+}
+
+// Function to format PMI scores as "word1 word2 pmi count" lines
+func formatPMI(scores []weightedTerm, bigramFreq map[string]int) []string {
+	var lines []string
+	for _, s := range scores {
+		words := outputTerms(strings.SplitN(s.Term, " ", 2))
+		lines = append(lines, fmt.Sprintf("%s %s %.4f %d", words[0], words[1], s.Weight, bigramFreq[s.Term]))
+	}
+	return lines
+}
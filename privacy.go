@@ -0,0 +1,28 @@
+package main
+
+// Function to coarsen the counts of every frequency map for publishing (-round-counts,
+// -suppress-below): counts are rounded to the nearest multiple of step (halves round up), and
+// terms whose count is below minCount, or rounds to 0, are removed. Returns how many terms were removed.
+func (a *analysis) roundCounts(step, minCount int) (suppressed int) {
+	for _, freqMap := range []map[string]int{a.chineseCharFreq, a.chineseWordsFreq, a.englishWordFreq,
+		a.englishPhrasesFreq, a.urlFreq, a.emailFreq, a.hashtagFreq, a.mentionFreq, a.lineFreq, a.mixedFreq, a.scriptFreq,
+		a.romanFreq} {
+		for term, count := range freqMap {
+			if count < minCount {
+				delete(freqMap, term)
+				suppressed++
+				continue
+			}
+			if step > 1 {
+				count = (count + step/2) / step * step
+			}
+			if count == 0 {
+				delete(freqMap, term)
+				suppressed++
+				continue
+			}
+			freqMap[term] = count
+		}
+	}
+	return suppressed
+}
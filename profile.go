@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Function to start CPU profiling to cpuPath (if set); the returned function stops it and writes
+// a heap profile to memPath (if set). It must run on every exit path, so main defers it.
+func startProfiling(cpuPath, memPath string) (stop func(), err error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		if cpuFile, err = os.Create(cpuPath); err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, err
+		}
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				fmt.Printf("Error writing CPU profile %s: %v\n", cpuPath, err)
+			}
+		}
+		if memPath != "" {
+			writeHeapProfile(memPath)
+		}
+	}, nil
+}
+
+// Helper function to write a heap profile of the objects still allocated after a garbage collection
+func writeHeapProfile(path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Error creating memory profile %s: %v\n", path, err)
+		return
+	}
+	defer file.Close()
+
+	runtime.GC() // Get up-to-date statistics
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		fmt.Printf("Error writing memory profile %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Minimum time between two progress updates
+const progressInterval = 500 * time.Millisecond
+
+// Progress of reading input files of a known total size, with an ETA derived from the throughput so far
+type progress struct {
+	mu      sync.Mutex // Guards done and printed when files are read concurrently (-workers)
+	total   int64
+	done    int64
+	start   time.Time
+	printed time.Time
+}
+
+// Function to start tracking progress over total bytes
+func newProgress(total int64) *progress {
+	now := time.Now()
+	return &progress{total: total, start: now, printed: now}
+}
+
+// Function to wrap a reader so its reads advance the progress
+func (p *progress) reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, p: p}
+}
+
+// Function to record n more bytes read, printing an update at most every progressInterval
+func (p *progress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += int64(n)
+	now := time.Now()
+	if now.Sub(p.printed) < progressInterval {
+		return
+	}
+	p.printed = now
+	p.print(now)
+}
+
+// Helper function to print the progress line, overwriting the previous one
+func (p *progress) print(now time.Time) {
+	if p.total <= 0 || p.done <= 0 {
+		return
+	}
+	elapsed := now.Sub(p.start)
+	remaining := time.Duration(float64(elapsed) * float64(p.total-p.done) / float64(p.done))
+	fmt.Printf("\rProgress: %5.1f%% (%d/%d bytes), ETA %s   ",
+		100*float64(p.done)/float64(p.total), p.done, p.total, remaining.Round(time.Second))
+}
+
+// Function to finish the progress line
+func (p *progress) finish() {
+	fmt.Printf("\rProgress: 100.0%% (%d bytes) in %s%-20s\n", p.done, time.Since(p.start).Round(time.Millisecond), "")
+}
+
+// Reader reporting the bytes read to a progress
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.p.add(n)
+	return n, err
+}
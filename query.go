@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Function to choose the category a query term belongs to: text with Chinese characters (as
+// matched by the scan, including a -chinese-char-regex override) is a Chinese character (single
+// rune) or Chinese word, other text is an English word or, with spaces, a phrase.
+// Returns the category's display name and result key, and the term normalized like its keys.
+func queryCategory(term string) (name, category, key string) {
+	if chineseCharPattern.MatchString(term) {
+		if utf8.RuneCountInString(term) == 1 {
+			return "Chinese characters", "chinese_characters", term
+		}
+		return "Chinese words", "chinese_words", term
+	}
+	key = strings.ToLower(term)
+	if *diacriticFold {
+		key = foldDiacritics(key)
+	}
+	if *normalizeDigits {
+		key = strings.ReplaceAll(key, strings.ToLower(numPlaceholder), numPlaceholder) // Keys hold the placeholder as is
+	}
+	if strings.ContainsAny(term, " \t") {
+		return "English phrases", "english_phrases", key
+	}
+	return "English words", "english_words", key
+}
+
+// Helper function to get the 1-based frequency rank of a term; terms with equal counts share a rank
+func frequencyRank(freqMap map[string]int, term string) int {
+	rank := 1
+	for _, count := range freqMap {
+		if count > freqMap[term] {
+			rank++
+		}
+	}
+	return rank
+}
+
+// Function to print the frequency and rank of each query term
+func printQueries(a *analysis, terms []string) {
+	fmt.Println()
+	fmt.Println("Query results:")
+	r := newResult(a)
+	for _, term := range terms {
+		name, category, key := queryCategory(term)
+		count, ok := r.count(category, key)
+		if !ok {
+			fmt.Printf("%s: not found (%s)\n", term, name)
+			continue
+		}
+		fmt.Printf("%s: count %d, rank %d of %d (%s)\n", term, count, r.rank(category, key), r.distinct(category), name)
+	}
+}
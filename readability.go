@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matches the end of an English sentence: a run of terminal punctuation followed by whitespace or the line end
+const sentenceEndRegex = `[.!?]+(?:\s|$)`
+
+// Compiled sentence end pattern, matched on every line
+var sentenceEndPattern = regexp.MustCompile(sentenceEndRegex)
+
+// Helper function to estimate the syllables of an English word by counting vowel groups,
+// discounting a silent final "e" (but not "-le", as in "table"); every word has at least one
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	inVowels := false
+	for _, r := range word {
+		isVowel := strings.ContainsRune("aeiouy", r)
+		if isVowel && !inVowels {
+			count++
+		}
+		inVowels = isVowel
+	}
+
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// Function to print English readability scores to the summary report: total and average
+// syllables, Flesch Reading Ease and Flesch-Kincaid Grade Level. Tokens without letters
+// (numbers) are not counted as words.
+func printReadability(a *analysis) {
+	words, syllables := 0, 0
+	letters := regexp.MustCompile(`[a-zA-Z]`)
+	for _, word := range a.englishWordList {
+		if !letters.MatchString(word) {
+			continue
+		}
+		words++
+		syllables += countSyllables(word)
+	}
+	if words == 0 {
+		return
+	}
+
+	// Text without terminal punctuation still forms one sentence
+	sentences := a.englishSentences
+	if sentences == 0 {
+		sentences = 1
+	}
+
+	wordsPerSentence := float64(words) / float64(sentences)
+	syllablesPerWord := float64(syllables) / float64(words)
+	fmt.Printf("English readability: %d sentences, %d syllables (%.2f per word)\n", sentences, syllables, syllablesPerWord)
+	fmt.Printf("  Flesch Reading Ease: %.1f\n", 206.835-1.015*wordsPerSentence-84.6*syllablesPerWord)
+	fmt.Printf("  Flesch-Kincaid Grade Level: %.1f\n", 0.39*wordsPerSentence+11.8*syllablesPerWord-15.59)
+}
@@ -0,0 +1,21 @@
+package main
+
+import "regexp"
+
+// Compiled -region-regex, nil unless set
+var regionPattern *regexp.Regexp
+
+// Function to tokenize only the regions of a line matched by -region-regex: the first capturing
+// group of each match if the regex has one (e.g. `"([^"]*)"` for quoted text), else the whole
+// match. Each region is processed like a line of its own, so no word or phrase spans two regions.
+func (a *analysis) processRegions(line string) {
+	for _, match := range regionPattern.FindAllStringSubmatch(line, -1) {
+		region := match[0]
+		if len(match) > 1 {
+			region = match[1]
+		}
+		if region != "" {
+			a.processLine(region)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import "sort"
+
+// Queryable results of an analysis: the deduplicated categories keyed like the JSON results
+// (e.g. "english_words"). The tree has no separate library package yet, so this is the API the
+// server, templates and queries build on instead of reaching into the raw maps.
+type result struct {
+	freq map[string]map[string]int
+}
+
+// Function to wrap the results of an analysis
+func newResult(a *analysis) *result {
+	return &result{freq: map[string]map[string]int{
+		"chinese_characters": a.chineseCharFreq,
+		"chinese_words":      a.chineseWordsFreq,
+		"english_words":      a.englishWordFreq,
+		"english_phrases":    a.englishPhrasesFreq,
+	}}
+}
+
+// Function to list the category names in alphabetical order
+func (r *result) categories() []string {
+	names := make([]string, 0, len(r.freq))
+	for name := range r.freq {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Function to get the n most frequent entries of a category (all of them if n <= 0), in the
+// order of sortByFrequency and with -hash-terms applied; nil for an unknown category
+func (r *result) top(category string, n int) []jsonEntry {
+	freqMap, ok := r.freq[category]
+	if !ok {
+		return nil
+	}
+	sorted := sortByFrequency(freqMap)
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return newJSONEntries(sorted, freqMap)
+}
+
+// Function to get the number of distinct terms of a category
+func (r *result) distinct(category string) int {
+	return len(r.freq[category])
+}
+
+// Function to get the count of a term in a category; the term must be normalized like the
+// category's keys, e.g. by queryCategory
+func (r *result) count(category, term string) (int, bool) {
+	count, ok := r.freq[category][term]
+	return count, ok
+}
+
+// Function to get the 1-based frequency rank of a term in a category, 0 if it wasn't counted.
+// Terms with equal counts share a rank.
+func (r *result) rank(category, term string) int {
+	if _, ok := r.count(category, term); !ok {
+		return 0
+	}
+	return frequencyRank(r.freq[category], term)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Delay before the first retry of a failed input read; it doubles with every further attempt
+const retryDelay = 100 * time.Millisecond
+
+// Errors of a system call that may go away when retried: an interrupted call, a busy or
+// temporarily unavailable resource, a timeout, or an I/O error or stale handle on a network filesystem
+var transientErrnos = []syscall.Errno{syscall.EINTR, syscall.EAGAIN, syscall.EBUSY, syscall.ETIMEDOUT, syscall.EIO, syscall.ESTALE}
+
+// Helper function to tell whether an I/O error may go away when retried. Only the errors listed
+// in transientErrnos and timeouts are; anything else, such as a missing file, denied permission
+// or a directory given as input, is final.
+func isTransient(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to retry an operation on path that failed with err, calling attempt until it
+// succeeds, fails with a final error or has been retried -retries times, sleeping retryDelay
+// before the first retry and twice as long before each further one
+func retry(path string, err error, attempt func() error) error {
+	delay := retryDelay
+	for i := 0; i < *retries && err != nil && isTransient(err); i++ {
+		fmt.Printf("Retrying %s in %s after error: %v\n", path, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+		err = attempt()
+	}
+	return err
+}
+
+// Input file that is reopened at the offset read so far when a read fails transiently (-retries)
+type retryFile struct {
+	path   string
+	file   *os.File
+	offset int64
+}
+
+// Function to open an input file, retrying transient failures
+func openRetrying(path string) (*retryFile, error) {
+	file, err := os.Open(path)
+	f := &retryFile{path: path, file: file}
+	err = retry(path, err, func() (err error) {
+		f.file, err = os.Open(path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *retryFile) Read(b []byte) (int, error) {
+	n, err := f.file.Read(b)
+	f.offset += int64(n)
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+	if n > 0 {
+		return n, nil // The error recurs on the next read, which is retried
+	}
+	err = retry(f.path, err, func() error {
+		reopened, err := os.Open(f.path)
+		if err != nil {
+			return err
+		}
+		if _, err = reopened.Seek(f.offset, io.SeekStart); err == nil {
+			n, err = reopened.Read(b)
+		}
+		if err != nil && err != io.EOF {
+			reopened.Close()
+			return err
+		}
+		f.file.Close()
+		f.file = reopened
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	f.offset += int64(n)
+	return n, nil
+}
+
+// Function to move the read position to offset, as when resuming a file from a checkpoint
+func (f *retryFile) seek(offset int64) error {
+	if _, err := f.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	f.offset = offset
+	return nil
+}
+
+func (f *retryFile) Close() error {
+	return f.file.Close()
+}
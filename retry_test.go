@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// Function to check which I/O errors are retried
+func TestIsTransient(t *testing.T) {
+	pathError := func(err error) error { return &fs.PathError{Op: "read", Path: "input.txt", Err: err} }
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{pathError(syscall.EINTR), true},
+		{pathError(syscall.EAGAIN), true},
+		{pathError(syscall.EBUSY), true},
+		{pathError(syscall.ETIMEDOUT), true},
+		{pathError(syscall.ESTALE), true},
+		{os.ErrDeadlineExceeded, true},
+		{pathError(syscall.EISDIR), false},
+		{pathError(syscall.ENOTDIR), false},
+		{pathError(syscall.ENAMETOOLONG), false},
+		{pathError(syscall.ENOENT), false},
+		{pathError(syscall.EACCES), false},
+		{os.ErrClosed, false},
+		{errors.New("unexpected"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
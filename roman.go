@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matches candidate Roman numerals: upper-case runs of at least two numeral letters, as
+// lower-case runs ("mix", "did") and single letters (the pronoun "I") are nearly always words
+const romanNumeralRegex = `\b[IVXLCDM]{2,}\b`
+
+// Compiled romanNumeralRegex
+var romanNumeralPattern = regexp.MustCompile(romanNumeralRegex)
+
+// Upper-case words and abbreviations that are also well-formed Roman numerals
+var romanLookalikes = map[string]bool{
+	"MIX": true, "DIX": true, "CD": true, "DC": true, "CV": true, "MD": true, "MC": true,
+	"MM": true, "CC": true, "CL": true, "DL": true, "LI": true, "MI": true, "DI": true, "XL": true,
+}
+
+// Values of the Roman numeral symbols, including the subtractive pairs, largest first
+var romanSymbols = []struct {
+	symbol string
+	value  int
+}{
+	{"M", 1000}, {"CM", 900}, {"D", 500}, {"CD", 400}, {"C", 100}, {"XC", 90},
+	{"L", 50}, {"XL", 40}, {"X", 10}, {"IX", 9}, {"V", 5}, {"IV", 4}, {"I", 1},
+}
+
+// Function to get the value of a Roman numeral, or 0 if it isn't one in canonical form
+// (1 to 3999): the symbols are summed, then the value is formatted back and compared, which
+// rejects malformed runs like "DID", "IIII" or "VX"
+func romanValue(numeral string) int {
+	value, rest := 0, numeral
+	for _, s := range romanSymbols {
+		for strings.HasPrefix(rest, s.symbol) {
+			value += s.value
+			rest = rest[len(s.symbol):]
+		}
+	}
+	if rest != "" || value == 0 || value > 3999 || formatRoman(value) != numeral {
+		return 0
+	}
+	return value
+}
+
+// Helper function to format a value as a canonical Roman numeral
+func formatRoman(value int) string {
+	var b strings.Builder
+	for _, s := range romanSymbols {
+		for value >= s.value {
+			b.WriteString(s.symbol)
+			value -= s.value
+		}
+	}
+	return b.String()
+}
+
+// Function to count the Roman numerals of a line into their category (-roman-numerals),
+// returning the line with them replaced by entityBreak so they aren't counted as English words
+func (a *analysis) extractRomanNumerals(line string) string {
+	return romanNumeralPattern.ReplaceAllStringFunc(line, func(match string) string {
+		if romanLookalikes[match] || romanValue(match) == 0 {
+			return match
+		}
+		a.romanFreq[match] += a.weight
+		return entityBreak
+	})
+}
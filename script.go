@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Compiled words pattern of the -script script, nil unless set
+var scriptPattern *regexp.Regexp
+
+// Function to set up counting the words of a Unicode script such as "Cyrillic" or "Greek":
+// runs of its letters and the combining marks that follow them (needed for Arabic, Devanagari, ...)
+func setScript(name string) error {
+	if _, ok := unicode.Scripts[name]; !ok {
+		var names []string
+		for script := range unicode.Scripts {
+			names = append(names, script)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%w: unknown script %q (known: %s)", ErrInvalidOption, name, strings.Join(names, ", "))
+	}
+	scriptPattern = regexp.MustCompile(fmt.Sprintf(`\p{%s}[\p{%s}\p{M}]*`, name, name))
+	return nil
+}
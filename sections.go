@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Compiled -section-regex, nil unless set
+var sectionPattern *regexp.Regexp
+
+// Section of the input started by a line matching -section-regex; its words are the word
+// list entries from the start indexes up to the header indexes of the next section
+type section struct {
+	Title                       string
+	EnglishHeader, EnglishStart int // Word list indexes of the header line's words and of the section's first word
+	ChineseHeader, ChineseStart int
+}
+
+// Function to start a new section after its header line has been processed; the header's
+// own words (often a date) count towards no section. englishHeader and chineseHeader are
+// the word list lengths before the header line.
+func (a *analysis) startSection(title string, englishHeader, chineseHeader int) {
+	a.sections = append(a.sections, section{
+		Title:         strings.Join(strings.Fields(title), " "), // Tabs would break the matrix columns
+		EnglishHeader: englishHeader,
+		EnglishStart:  len(a.englishWordList),
+		ChineseHeader: chineseHeader,
+		ChineseStart:  len(a.chineseWordsList),
+	})
+}
+
+// Function to format a term × section matrix of counts as tab-separated lines: a header row
+// with the section titles, then one row per English or Chinese word, most frequent overall first.
+// Words before the first header form a leading "(start)" section.
+func formatSectionMatrix(a *analysis) []string {
+	sections := a.sections
+	if len(sections) == 0 || sections[0].EnglishHeader > 0 || sections[0].ChineseHeader > 0 {
+		sections = append([]section{{Title: "(start)"}}, sections...)
+	}
+
+	// Count each section's words
+	totals := make(map[string]int)
+	counts := make([]map[string]int, len(sections))
+	for i, s := range sections {
+		englishEnd, chineseEnd := len(a.englishWordList), len(a.chineseWordsList)
+		if i+1 < len(sections) {
+			englishEnd, chineseEnd = sections[i+1].EnglishHeader, sections[i+1].ChineseHeader
+		}
+
+		counts[i] = make(map[string]int)
+		for _, word := range a.englishKeys(s.EnglishStart, englishEnd) {
+			counts[i][word]++
+		}
+		for _, word := range a.chineseWordsList[s.ChineseStart:chineseEnd] {
+			counts[i][word]++
+		}
+		for term, count := range counts[i] {
+			totals[term] += count
+		}
+	}
+
+	titles := make([]string, len(sections))
+	for i, s := range sections {
+		titles[i] = s.Title
+	}
+	header := append([]string{"term"}, outputTerms(titles)...)
+	lines := []string{strings.Join(header, "\t")}
+
+	for _, term := range sortByFrequency(totals) {
+		row := []string{outputTerms([]string{term})[0]}
+		for i := range sections {
+			row = append(row, fmt.Sprint(counts[i][term]))
+		}
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+	return lines
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of co-occurring words listed per seed term
+const seedNeighbors = 10
+
+// Default co-occurrence window of the seed report when -cooccurrence is not set
+const seedWindow = 5
+
+// Function to build the focused report of the seed terms: for each term its count and rank in
+// its category and, for English and Chinese words, the words most often found within the
+// co-occurrence window around it
+func formatSeedReport(a *analysis, seeds []string) []string {
+	window := *cooccurrenceWindow
+	if window <= 0 {
+		window = seedWindow
+	}
+
+	r := newResult(a)
+	var lines []string
+	for _, seed := range seeds {
+		name, category, key := queryCategory(seed)
+		lines = append(lines, fmt.Sprintf("== %s ==", outputTerms([]string{seed})[0]))
+		count, ok := r.count(category, key)
+		if !ok {
+			lines = append(lines, fmt.Sprintf("not found (%s)", name))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("count %d, rank %d of %d (%s)", count, r.rank(category, key), r.distinct(category), name))
+
+		var tokens []string
+		switch category {
+		case "english_words":
+			tokens = a.englishKeyList
+		case "chinese_words":
+			tokens = a.chineseWordsList
+		default:
+			continue
+		}
+
+		neighbors := countNeighbors(tokens, key, window)
+		sorted := sortByFrequency(neighbors)
+		if len(sorted) > seedNeighbors {
+			sorted = sorted[:seedNeighbors]
+		}
+		parts := make([]string, len(sorted))
+		for i, word := range outputTerms(sorted) {
+			parts[i] = fmt.Sprintf("%s:%d", word, neighbors[sorted[i]])
+		}
+		lines = append(lines, "co-occurring: "+strings.Join(parts, " "))
+	}
+	return lines
+}
+
+// Helper function to count the words within window tokens of each occurrence of term
+func countNeighbors(tokens []string, term string, window int) map[string]int {
+	neighbors := make(map[string]int)
+	for i, token := range tokens {
+		if token != term {
+			continue
+		}
+		for j := i - window; j <= i+window; j++ {
+			if j < 0 || j >= len(tokens) || j == i {
+				continue
+			}
+			if neighbor := tokens[j]; neighbor != term {
+				neighbors[neighbor]++
+			}
+		}
+	}
+	return neighbors
+}
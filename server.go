@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Frequency entry of the JSON results
+type jsonEntry struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// Function to build the entries of sorted terms with their counts (and -hash-terms applied)
+func newJSONEntries(sortedTerms []string, freqMap map[string]int) []jsonEntry {
+	terms := outputTerms(sortedTerms)
+	entries := make([]jsonEntry, len(sortedTerms))
+	for i, term := range sortedTerms {
+		entries[i] = jsonEntry{Term: terms[i], Count: freqMap[term]}
+	}
+	return entries
+}
+
+// JSON results: deduplicated entries per category, most frequent first
+type jsonResult struct {
+	Categories map[string][]jsonEntry `json:"categories"`
+}
+
+// Function to build the JSON results of an analysis
+func newJSONResult(a *analysis) jsonResult {
+	r := newResult(a)
+	result := jsonResult{Categories: make(map[string][]jsonEntry)}
+	for _, category := range r.categories() {
+		result.Categories[category] = r.top(category, 0)
+	}
+	return result
+}
+
+// Function to run the HTTP server exposing the analysis:
+//   - POST /analyze: analyze the request body (plain text, or a multipart upload in the "file" field)
+//     and respond with the JSON results
+//   - GET /health: respond "ok" when the server is up
+func serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/analyze", handleAnalyze)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	fmt.Printf("Listening on %s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// Handler of POST /analyze
+func handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, *maxRequestBytes)
+
+	// Accept either a file upload or the raw text body
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid upload: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	a := newAnalysis()
+	err := a.scan("request", body)
+	if err == nil && a.files[0].Lines == 0 {
+		err = ErrEmptyInput
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		status := http.StatusBadRequest
+		switch {
+		case errors.As(err, &maxBytesErr):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, ErrUnsupportedEncoding):
+			status = http.StatusUnsupportedMediaType
+		}
+		http.Error(w, fmt.Sprintf("error reading input: %v", err), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newJSONResult(a)); err != nil {
+		fmt.Printf("Error writing response: %v\n", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Helper function to get the shard key of an output term: its first character, lower-cased, if
+// it is a letter or digit, otherwise "_". Keys are letters and digits only, so they are safe in
+// file names, and lower-casing keeps "A" and "a" apart from case-insensitive file systems.
+func shardKey(term string) string {
+	first, _ := utf8.DecodeRuneInString(term)
+	if !unicode.IsLetter(first) && !unicode.IsDigit(first) {
+		return "_"
+	}
+	return string(unicode.ToLower(first))
+}
+
+// Function to write a frequency-sorted category split into one file per shard key instead of a
+// single file (-shard), e.g. "deduplicated_english_a.txt" or "deduplicated_chinese_中.txt".
+// Keys are taken from the written terms, so with -hash-terms they are hex digits of the hashes.
+func writeShards(filePath string, sortedTerms []string, freqMap map[string]int) {
+	base := strings.TrimSuffix(filePath, ".txt")
+	shards := make(map[string][]string)
+	for i, term := range outputTerms(sortedTerms) {
+		key := shardKey(term)
+		shards[key] = append(shards[key], sortedTerms[i])
+	}
+
+	keys := make([]string, 0, len(shards))
+	for key := range shards {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeDeduplicated(base+"_"+key+".txt", shards[key], freqMap)
+	}
+}
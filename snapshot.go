@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Trigger of periodic snapshots, every fixed number of lines or after a fixed duration
+type flusher struct {
+	lines    int
+	interval time.Duration
+	count    int
+	last     time.Time
+	write    func()
+}
+
+// Function to create a flusher from a -flush-interval value: a line count like "100000"
+// or a duration like "30s"
+func newFlusher(spec string, write func()) (*flusher, error) {
+	if lines, err := strconv.Atoi(spec); err == nil {
+		if lines <= 0 {
+			return nil, fmt.Errorf("%w: line count must be positive, got %d", ErrInvalidOption, lines)
+		}
+		return &flusher{lines: lines, write: write}, nil
+	}
+
+	interval, err := time.ParseDuration(spec)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("%w: %q is neither a positive line count nor a duration", ErrInvalidOption, spec)
+	}
+	return &flusher{interval: interval, last: time.Now(), write: write}, nil
+}
+
+// Function to count an analyzed line and write a snapshot when due
+func (f *flusher) tick() {
+	f.count++
+	if f.lines > 0 {
+		if f.count%f.lines == 0 {
+			f.write()
+		}
+		return
+	}
+
+	// Checking the clock every 1024 lines keeps the per-line overhead negligible
+	if f.count%1024 == 0 && time.Since(f.last) >= f.interval {
+		f.write()
+		f.last = time.Now()
+	}
+}
+
+// Function to write the current deduplicated Chinese character and English word frequencies
+func writeSnapshot(a *analysis, chineseFile, englishFile string) {
+	for _, out := range []struct {
+		path    string
+		freqMap map[string]int
+	}{
+		{chineseFile, a.chineseCharFreq},
+		{englishFile, a.englishWordFreq},
+	} {
+		path := out.path
+		if *compressOutput {
+			path += ".gz"
+		}
+		if err := writeFileAtomic(path, outputTerms(sortByFrequency(out.freqMap))); err != nil {
+			fmt.Printf("Error writing snapshot %s: %v\n", path, err)
+		}
+	}
+}
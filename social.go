@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Regex patterns of social media entities; a hashtag needs at least one letter so "#1" is not one
+const (
+	hashtagRegex = `[#＃][\p{L}\p{M}\p{N}_]*[\p{L}_][\p{L}\p{M}\p{N}_]*` // Matches hashtags, also Unicode ones like "#中文" (full-width "＃" too)
+	mentionRegex = `[@＠][\p{L}\p{M}\p{N}_]+`                            // Matches mentions like "@user"
+)
+
+// Compiled social media patterns
+var (
+	hashtagPattern = regexp.MustCompile(hashtagRegex)
+	mentionPattern = regexp.MustCompile(mentionRegex)
+)
+
+// Function to extract hashtags and mentions from a line into their categories, returning the
+// line with them replaced by entityBreak so the English regexes don't count their words again.
+// A match only counts when it starts a word (e.g. not the "#" in "C#" or "a#b").
+func (a *analysis) extractSocial(line string) string {
+	line = extractEntities(line, hashtagPattern, func(tag string) {
+		a.hashtagFreq[normalizeSocial(tag, "#")] += a.weight
+		a.hashtagList = append(a.hashtagList, tag) // Append in original order
+	})
+	return extractEntities(line, mentionPattern, func(mention string) {
+		a.mentionFreq[normalizeSocial(mention, "@")] += a.weight
+		a.mentionList = append(a.mentionList, mention) // Append in original order
+	})
+}
+
+// Helper function to pass every word-initial match of re to record and replace it with entityBreak
+func extractEntities(line string, re *regexp.Regexp, record func(string)) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		if prev, _ := utf8.DecodeLastRuneInString(line[:loc[0]]); loc[0] > 0 &&
+			(unicode.IsLetter(prev) || unicode.IsDigit(prev) || prev == '_') {
+			continue
+		}
+		record(line[loc[0]:loc[1]])
+		b.WriteString(line[last:loc[0]])
+		b.WriteString(entityBreak)
+		last = loc[1]
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// Helper function to normalize a hashtag or mention: ASCII prefix and lowercase, as platforms
+// treat "#Go" and "＃go" as the same tag
+func normalizeSocial(entity, prefix string) string {
+	_, size := utf8.DecodeRuneInString(entity)
+	return prefix + strings.ToLower(entity[size:])
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+)
+
+// Standard output, kept for the results in -stdout mode while the messages printed with
+// fmt.Printf go to standard error
+var resultsOut io.Writer
+
+// Deduplicated category written to standard output with -stdout
+type stdoutCategory struct {
+	name        string // Key of the JSON results, e.g. "english_words"
+	sortedTerms []string
+	freqMap     map[string]int
+}
+
+// Function to write the deduplicated categories to standard output in the -format format:
+//   - txt: the lines of the deduplicated files; with several categories, each is preceded by a
+//     "== <category> ==" line
+//   - go: one source file declaring a map variable per category, e.g. englishWords
+//   - msgpack: the array of {"term", "count"} maps of the category; with several categories, a
+//     map from the category names to these arrays
+func writeStdout(categories []stdoutCategory) error {
+	switch *outputFormat {
+	case "go":
+		var buf bytes.Buffer
+		buf.WriteString(goSourceHeader)
+		for _, c := range categories {
+			writeGoMap(&buf, goIdentifier(c.name), c.sortedTerms, c.freqMap)
+		}
+		source, err := format.Source(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		_, err = resultsOut.Write(source)
+		return err
+	case "msgpack":
+		encoder := newMsgpackEncoder(resultsOut)
+		if len(categories) == 1 {
+			return encoder.Encode(newJSONEntries(categories[0].sortedTerms, categories[0].freqMap))
+		}
+		entries := make(map[string][]jsonEntry)
+		for _, c := range categories {
+			entries[c.name] = newJSONEntries(c.sortedTerms, c.freqMap)
+		}
+		encoder.SetSortMapKeys(true) // Deterministic output
+		return encoder.Encode(entries)
+	default:
+		var lines []string
+		for _, c := range categories {
+			if len(categories) > 1 {
+				lines = append(lines, fmt.Sprintf("== %s ==", c.name))
+			}
+			if *groupInitial {
+				lines = append(lines, groupByInitial(c.sortedTerms, *groupOrder == "alpha")...)
+			} else {
+				lines = append(lines, outputTerms(c.sortedTerms)...)
+			}
+		}
+		return writeLines(resultsOut, lines)
+	}
+}
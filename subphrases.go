@@ -0,0 +1,33 @@
+package main
+
+import "regexp"
+
+// Pattern of the words of a phrase, keeping the separators between them intact
+var phraseWordPattern = regexp.MustCompile(`\S+`)
+
+// Function to remove redundant English phrases (-dedupe-subphrases). A phrase P is a sub-phrase
+// of a longer phrase Q if P's words are a contiguous run of Q's words, e.g. "thank you" of
+// "thank you very much". Phrase matches are maximal, so every counted phrase was also seen on its
+// own; P is removed when some Q containing it is counted at least as often, i.e. P occurred
+// mostly as part of Q. Decisions use the counts before any removal. Returns how many were removed.
+func (a *analysis) dedupeSubphrases() int {
+	redundant := make(map[string]bool)
+	for phrase, count := range a.englishPhrasesFreq {
+		spans := phraseWordPattern.FindAllStringIndex(phrase, -1)
+		for i := range spans {
+			for j := i; j < len(spans); j++ {
+				if i == 0 && j == len(spans)-1 {
+					continue // The phrase itself
+				}
+				sub := phrase[spans[i][0]:spans[j][1]]
+				if subCount, ok := a.englishPhrasesFreq[sub]; ok && subCount <= count {
+					redundant[sub] = true
+				}
+			}
+		}
+	}
+	for phrase := range redundant {
+		delete(a.englishPhrasesFreq, phrase)
+	}
+	return len(redundant)
+}
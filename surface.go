@@ -0,0 +1,30 @@
+package main
+
+// Function to record the spelling an English word was counted under (-surface-forms)
+func (a *analysis) recordSurface(key, surface string) {
+	forms := a.wordForms[key]
+	if forms == nil {
+		forms = make(map[string]int)
+		a.wordForms[key] = forms
+	}
+	forms[surface] += a.weight
+}
+
+// Function to report the counted English words under their most frequent spelling
+// (-surface-forms), e.g. "iphone" as "iPhone". Ties go to the spelling that sorts first byte-wise.
+// Returns the renamed terms, in the order of sortedTerms, and their counts.
+func (a *analysis) surfaceForms(sortedTerms []string, freqMap map[string]int) ([]string, map[string]int) {
+	renamed := make([]string, len(sortedTerms))
+	counts := make(map[string]int, len(sortedTerms))
+	for i, key := range sortedTerms {
+		best, bestCount := key, 0
+		for surface, count := range a.wordForms[key] {
+			if count > bestCount || count == bestCount && surface < best {
+				best, bestCount = surface, count
+			}
+		}
+		renamed[i] = best
+		counts[best] += freqMap[key]
+	}
+	return renamed, counts
+}
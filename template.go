@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Data exposed to a -template: the analyzed files, the analyzed language and the deduplicated
+// entries per category (keyed like the JSON results, e.g. "english_words"), most frequent first
+type templateData struct {
+	Files      []fileStats
+	Language   string
+	Categories map[string][]jsonEntry
+}
+
+// Helper function to get the output file of a template: its name without a ".tmpl" suffix,
+// e.g. "report.md.tmpl" renders to "report.md"
+func templateOutputName(templatePath string) string {
+	name := filepath.Base(templatePath)
+	if trimmed := strings.TrimSuffix(name, ".tmpl"); trimmed != name && trimmed != "" {
+		return trimmed
+	}
+	return name + ".out"
+}
+
+// Function to render the results with a user-supplied text/template into filePath
+func writeTemplate(templatePath, filePath string, a *analysis, language string) error {
+	tmpl, err := template.ParseFiles(templatePath)
+	if err != nil {
+		return err
+	}
+	data := templateData{Files: a.files, Language: language, Categories: newJSONResult(a).Categories}
+
+	file, err := createAtomic(filePath)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(file, data); err != nil {
+		file.abort()
+		return fmt.Errorf("executing template: %v", err)
+	}
+	return file.commit()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Weighted term of a document
+type weightedTerm struct {
+	Term   string
+	Weight float64
+}
+
+// Function to compute TF-IDF weights of the English and Chinese words of every input file.
+// tf is the term count divided by the document's word count; idf uses the smoothed form
+// ln((1+N)/(1+df)) + 1, so terms shared by all N documents keep a small positive weight.
+func computeTFIDF(a *analysis) [][]weightedTerm {
+	docs := documentCounts(a)
+	docFreq := make(map[string]int)
+	for _, counts := range docs {
+		for term := range counts {
+			docFreq[term]++
+		}
+	}
+
+	// Weight each document's terms
+	n := float64(len(docs))
+	weights := make([][]weightedTerm, len(docs))
+	for i, counts := range docs {
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		for term, count := range counts {
+			tf := float64(count) / float64(total)
+			idf := math.Log((1+n)/(1+float64(docFreq[term]))) + 1
+			weights[i] = append(weights[i], weightedTerm{term, tf * idf})
+		}
+
+		// Highest weight first, ties alphabetically
+		sort.Slice(weights[i], func(x, y int) bool {
+			if weights[i][x].Weight != weights[i][y].Weight {
+				return weights[i][x].Weight > weights[i][y].Weight
+			}
+			return weights[i][x].Term < weights[i][y].Term
+		})
+	}
+
+	return weights
+}
+
+// Helper function to count the English (normalized) and Chinese words of every input file
+func documentCounts(a *analysis) []map[string]int {
+	docs := make([]map[string]int, len(a.files))
+	for i, f := range a.files {
+		counts := make(map[string]int)
+		for _, word := range a.englishKeys(f.EnglishWords[0], f.EnglishWords[1]) {
+			counts[word]++
+		}
+		for _, word := range a.chineseWordsList[f.ChineseWords[0]:f.ChineseWords[1]] {
+			counts[word]++
+		}
+		docs[i] = counts
+	}
+	return docs
+}
+
+// Function to format TF-IDF weights as one "== file ==" section per document with "term weight" lines
+func formatTFIDF(a *analysis, weights [][]weightedTerm) []string {
+	var lines []string
+	for i, f := range a.files {
+		lines = append(lines, fmt.Sprintf("== %s ==", f.Path))
+		for _, w := range weights[i] {
+			lines = append(lines, fmt.Sprintf("%s %.6f", outputTerms([]string{w.Term})[0], w.Weight))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,47 @@
+package main
+
+import "unicode"
+
+// Function to split text into words at Unicode word boundaries (-tokenizer unicode), a
+// simplified form of the UAX #29 rules: words are runs of letters, combining marks and digits
+// of any script except Han (counted by the Chinese categories), which continue across an
+// apostrophe between letters ("don't", "l’homme") and across "." or "," between digits
+// ("3.14", "1,000"). Everything else, hyphens included, separates words.
+func unicodeWords(text string) []string {
+	runes := []rune(text)
+	isWordRune := func(i int) bool {
+		r := runes[i]
+		return (unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)) && !unicode.Is(unicode.Han, r)
+	}
+	joins := func(i int) bool {
+		if i == 0 || i+1 >= len(runes) || !isWordRune(i-1) || !isWordRune(i+1) {
+			return false
+		}
+		switch runes[i] {
+		case '\'', '’':
+			return unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+		case '.', ',':
+			return unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])
+		}
+		return false
+	}
+
+	var words []string
+	start := -1
+	for i := range runes {
+		if isWordRune(i) || (start >= 0 && joins(i)) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Replacements of typographic punctuation by its ASCII equivalent, applied after NFKC (which
+// leaves these characters alone). Em dashes become the ASCII convention "--", so the words
+// around them aren't joined into one hyphenated compound.
+var typographyReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "−", "-",
+	"—", "--", "―", "--",
+	"\u00ad", "", "\u200b", "", "\ufeff", "", // Soft hyphens and zero-width characters
+)
+
+// Invisible characters removed before tokenizing by default (-strip-invisible): zero-width space,
+// word joiner, zero-width no-break space (a byte order mark anywhere but at the start of a file)
+// and soft hyphen. They silently split "中\u200b文" or "proc\u00adess" into two tokens, or make
+// a token look like a duplicate of one without them. Joiners that shape text (ZWJ, ZWNJ) are kept.
+var invisibleReplacer = strings.NewReplacer("\u200b", "", "\u2060", "", "\ufeff", "", "\u00ad", "")
+
+// Function to undo typography that fragments tokens in text extracted from PDFs
+// (-normalize-ligatures): NFKC decomposes ligatures like "ﬁ" into "fi" (and full-width forms into
+// ASCII), then smart quotes and dashes are mapped to ASCII
+func normalizeTypography(line string) string {
+	return typographyReplacer.Replace(norm.NFKC.String(line))
+}
+
+// Function to fold diacritics for counting (-fold-diacritics): NFD separates accents from their
+// base letters, the combining marks are dropped and the rest is recomposed (NFC), so "café" and
+// "naïve" count as "cafe" and "naive". Letters without a decomposition, like "ø" or "ß", are kept.
+func foldDiacritics(term string) string {
+	decomposed := norm.NFD.String(term)
+	folded := strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, decomposed)
+	return norm.NFC.String(folded)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Function to check the parsed options up front, so invalid values and combinations are
+// reported before any work is done instead of producing surprising output
+func validateFlags() error {
+	// Options given on the command line or through the environment
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// Option values
+	if !inCatalog(formatCatalog, *outputFormat) {
+		return fmt.Errorf("%w: unsupported -format %q", ErrInvalidOption, *outputFormat)
+	}
+	if *tokenizer != "regex" && *tokenizer != "unicode" {
+		return fmt.Errorf("%w: unsupported -tokenizer %q", ErrInvalidOption, *tokenizer)
+	}
+	if *groupOrder != "freq" && *groupOrder != "alpha" {
+		return fmt.Errorf("%w: unsupported -group-order %q", ErrInvalidOption, *groupOrder)
+	}
+	if *minWordLength < 1 {
+		return fmt.Errorf("%w: -min-word-length %d must be at least 1", ErrInvalidOption, *minWordLength)
+	}
+	if *ioThreads < 0 || *ioBuffer < 1 {
+		return fmt.Errorf("%w: -threads-io %d must not be negative and -io-buffer %d must be positive", ErrInvalidOption, *ioThreads, *ioBuffer)
+	}
+	if *workers < 1 {
+		return fmt.Errorf("%w: -workers %d must be at least 1", ErrInvalidOption, *workers)
+	}
+	if *externalMaxTerms < 1 {
+		return fmt.Errorf("%w: -external-max-terms %d must be at least 1", ErrInvalidOption, *externalMaxTerms)
+	}
+	if *roundStep < 0 || *suppressBelow < 0 {
+		return fmt.Errorf("%w: -round-counts and -suppress-below must not be negative", ErrInvalidOption)
+	}
+	if *chunkSize < 0 || *chunkOverlap < 0 || (*chunkSize > 0 && *chunkOverlap >= *chunkSize) {
+		return fmt.Errorf("%w: -chunk-overlap %d must be below -chunk-size %d", ErrInvalidOption, *chunkOverlap, *chunkSize)
+	}
+	if *retries < 0 {
+		return fmt.Errorf("%w: -retries %d must not be negative", ErrInvalidOption, *retries)
+	}
+	if *positionBuckets < 0 {
+		return fmt.Errorf("%w: -position-buckets %d must not be negative", ErrInvalidOption, *positionBuckets)
+	}
+	if *skipLines < 0 {
+		return fmt.Errorf("%w: -skip-lines %d must not be negative", ErrInvalidOption, *skipLines)
+	}
+	if *startLine < 1 || *endLine < 0 || (*endLine > 0 && *endLine < *startLine) {
+		return fmt.Errorf("%w: line range -start-line %d, -end-line %d", ErrInvalidOption, *startLine, *endLine)
+	}
+
+	// Options that only take effect together with another one
+	dependents := []struct {
+		name, requires string
+		active         bool
+	}{
+		{"salt", "hash-terms", *hashTerms},
+		{"columns", "top or -tail", *topN > 0 || *tailN > 0},
+		{"group-order", "group-by-initial", *groupInitial},
+		{"collate", "group-by-initial", *groupInitial},
+		{"cooccurrence-min", "cooccurrence", *cooccurrenceWindow > 0},
+		{"mutual-information-min", "mutual-information", *mutualInformation},
+		{"byte-lengths", "longest", *longestN > 0},
+		{"external-max-terms", "external", *external},
+		{"max-request-bytes", "serve", *serveAddr != ""},
+		{"position-buckets", "inverted-index", *invertedIndex},
+		{"chunk-overlap", "chunk-size", *chunkSize > 0},
+		{"io-buffer", "threads-io", *ioThreads > 0},
+	}
+	for _, d := range dependents {
+		if set[d.name] && !d.active {
+			return fmt.Errorf("%w: -%s has no effect without -%s", ErrInvalidOption, d.name, d.requires)
+		}
+	}
+
+	// Options that exclude each other
+	if *outputFormat == "graphml" && *cooccurrenceWindow <= 0 {
+		return fmt.Errorf("%w: -format graphml has no effect without -cooccurrence", ErrInvalidOption)
+	}
+	if *groupInitial && *outputFormat != "txt" && *outputFormat != "graphml" {
+		return fmt.Errorf("%w: -group-by-initial only applies to -format txt", ErrInvalidOption)
+	}
+	if *tokenizer == "unicode" && *englishWordRe != "" {
+		return fmt.Errorf("%w: -english-word-regex only applies to -tokenizer regex", ErrInvalidOption)
+	}
+	if *chunkSize > 0 && (*weighted || *lineFrequency || *perLineStats || *sectionRegex != "") {
+		return fmt.Errorf("%w: -chunk-size cannot be combined with -weighted, -line-frequency, -per-line-stats or -section-regex, which need whole lines", ErrInvalidOption)
+	}
+	if *validateCounts && (*weighted || *splitIdentifiers) {
+		return fmt.Errorf("%w: -validate cannot be combined with -weighted or -split-identifiers, whose counts differ from the duplicated lists", ErrInvalidOption)
+	}
+	if (*roundStep > 0 || *suppressBelow > 0) && (*cooccurrenceWindow > 0 || *tfidf || *docFreq || *sectionRegex != "" || *mutualInformation || *seedTermsPath != "") {
+		// These reports recount the duplicated lists, which keep every exact occurrence
+		return fmt.Errorf("%w: -round-counts and -suppress-below cannot be combined with -cooccurrence, -tfidf, -document-frequency, -section-regex, -mutual-information or -seed-terms", ErrInvalidOption)
+	}
+	if *kwicN > 0 && *hashTerms {
+		return fmt.Errorf("%w: -kwic cannot be combined with -hash-terms, since the context lines would disclose the terms", ErrInvalidOption)
+	}
+	if *weighted && *filterCmd != "" {
+		return fmt.Errorf("%w: -weighted cannot be combined with -filter-cmd, which recounts the duplicated lists", ErrInvalidOption)
+	}
+	if *workers > 1 && (*flushInterval != "" || *checkpointEvery > 0 || *resume) {
+		// Concurrent scanning can't take consistent snapshots or checkpoints of the shared state
+		return fmt.Errorf("%w: -workers cannot be combined with -flush-interval, -checkpoint-every or -resume", ErrInvalidOption)
+	}
+	if *ioThreads > 0 && (*flushInterval != "" || *checkpointEvery > 0 || *resume || *external || *sectionRegex != "" || *perLineStats) {
+		return fmt.Errorf("%w: -threads-io cannot be combined with -flush-interval, -checkpoint-every, -resume, -external, -section-regex or -per-line-stats", ErrInvalidOption)
+	}
+	if *external {
+		var conflicts []string
+		for _, name := range []string{"workers", "flush-interval", "checkpoint-every", "resume", "filter-cmd", "weighted", "validate"} {
+			if set[name] {
+				conflicts = append(conflicts, "-"+name)
+			}
+		}
+		if *outputFormat != "txt" {
+			conflicts = append(conflicts, "-format "+*outputFormat)
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("%w: -external cannot be combined with %s", ErrInvalidOption, strings.Join(conflicts, ", "))
+		}
+	}
+	if *toStdout && (*outputFormat == "parquet" || *external || *serveAddr != "" || *shard) {
+		return fmt.Errorf("%w: -stdout cannot be combined with -format parquet, -external, -serve or -shard", ErrInvalidOption)
+	}
+	if *mergeJSON && (flag.NArg() == 0 || *inputPath != "" || *serveAddr != "") {
+		return fmt.Errorf("%w: -merge-json takes the JSON results files as arguments, without -input or -serve", ErrInvalidOption)
+	}
+	if *serveAddr != "" && (*inputPath != "" || flag.NArg() > 0) {
+		return fmt.Errorf("%w: -serve analyzes uploaded text and takes no input file", ErrInvalidOption)
+	}
+	return nil
+}
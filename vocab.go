@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+// Function to format a vocabulary of the English and Chinese words as "id term count" lines,
+// ids assigned by descending frequency from 0, for seeding tokenizers and embeddings
+func formatVocab(a *analysis) []string {
+	counts := make(map[string]int, len(a.englishWordFreq)+len(a.chineseWordsFreq))
+	for term, count := range a.englishWordFreq {
+		counts[term] += count
+	}
+	for term, count := range a.chineseWordsFreq {
+		counts[term] += count
+	}
+
+	sorted := sortByFrequency(counts)
+	terms := outputTerms(sorted)
+	lines := make([]string, len(sorted))
+	for id, term := range sorted {
+		lines[id] = fmt.Sprintf("%d %s %d", id, terms[id], counts[term])
+	}
+	return lines
+}
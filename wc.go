@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reader counting the bytes and UTF-8 characters read through it; counting the bytes that
+// don't continue a multi-byte character is exact even when reads split a character
+type wcReader struct {
+	r     io.Reader
+	bytes int64
+	runes int64
+}
+
+func (wr *wcReader) Read(b []byte) (int, error) {
+	n, err := wr.r.Read(b)
+	wr.bytes += int64(n)
+	for _, c := range b[:n] {
+		if c&0xC0 != 0x80 {
+			wr.runes++
+		}
+	}
+	return n, err
+}
+
+// Function to print wc-style counts of each input file (-wc): lines, words, characters (runes)
+// and bytes, plus a total for several files. Words are counted the way the analysis tokenizes
+// them: English words plus Chinese characters, each Han character counting as one word.
+func printWordCounts(paths []string) error {
+	var total [4]int64
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		counter := &wcReader{r: file}
+		a := newAnalysis()
+		err = a.scan(path, counter)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("reading input file %s: %w", path, err)
+		}
+
+		counts := [4]int64{int64(a.files[0].Lines), int64(len(a.englishWordList) + len(a.chineseCharList)), counter.runes, counter.bytes}
+		fmt.Printf("%8d %8d %8d %8d %s\n", counts[0], counts[1], counts[2], counts[3], path)
+		for i := range total {
+			total[i] += counts[i]
+		}
+	}
+	if len(paths) > 1 {
+		fmt.Printf("%8d %8d %8d %8d total\n", total[0], total[1], total[2], total[3])
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// Reference frequency list bundled for -word-bands, used unless -reference-list names another
+//
+//go:embed reference_words.txt
+var bundledReferenceList string
+
+// Ranks in the reference list up to which English words are tagged "common" and "uncommon";
+// words ranked lower or not listed are "rare"
+const (
+	commonRank   = 2000
+	uncommonRank = 5000
+)
+
+// Function to load a reference frequency list (one word per line, most frequent first, as for
+// -dictionary) into the 1-based rank of each lower-cased word; a repeated word keeps its first rank.
+// An empty path loads the bundled list.
+func loadWordRanks(path string) (map[string]int, error) {
+	var list []string
+	var err error
+	if path == "" {
+		list, err = readWordList(strings.NewReader(bundledReferenceList))
+	} else {
+		list, err = loadWordList(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[string]int, len(list))
+	for i, word := range list {
+		word = strings.ToLower(word)
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks, nil
+}
+
+// Helper function to name the frequency band of a reference rank (0 for unlisted words)
+func wordBand(rank int) string {
+	switch {
+	case rank > 0 && rank <= commonRank:
+		return "common"
+	case rank > 0 && rank <= uncommonRank:
+		return "uncommon"
+	}
+	return "rare"
+}
+
+// Function to format the English words as "word count band" lines, most frequent first, tagging
+// each with its band in the reference list for learners to prioritize vocabulary
+func formatWordBands(freqMap map[string]int, ranks map[string]int) []string {
+	sorted := sortByFrequency(freqMap)
+	lines := make([]string, len(sorted))
+	for i, word := range outputTerms(sorted) {
+		lines[i] = fmt.Sprintf("%s %d %s", word, freqMap[sorted[i]], wordBand(ranks[sorted[i]]))
+	}
+	return lines
+}
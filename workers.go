@@ -0,0 +1,125 @@
+package main
+
+import (
+	"sync"
+)
+
+// Function to scan the input files concurrently, at most workers at a time. Each file is
+// counted into its own analysis; finished files are merged under a mutex strictly in input
+// order, so the results (including the order of the duplicated lists) don't depend on which
+// file finishes first.
+func (a *analysis) scanFilesConcurrently(paths []string, workers int) (failed string, err error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make([]*analysis, len(paths))
+		errs    = make([]error, len(paths))
+		next    = 0 // Index of the next file to merge
+	)
+
+	sem := make(chan struct{}, workers)
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			local := newAnalysis()
+			local.progress = a.progress
+			err := local.scanFile(path)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[i], errs[i] = local, err
+			for next < len(paths) && results[next] != nil {
+				if errs[next] == nil {
+					a.merge(results[next])
+				}
+				results[next] = nil // Release the merged maps and lists
+				next++
+			}
+		}(i, path)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return paths[i], err
+		}
+	}
+	return "", nil
+}
+
+// Function to append the counts, lists and file statistics of another analysis
+func (a *analysis) merge(b *analysis) {
+	mergeFreq := func(dst, src map[string]int) {
+		for term, count := range src {
+			dst[term] += count
+		}
+	}
+	mergeFreq(a.chineseCharFreq, b.chineseCharFreq)
+	mergeFreq(a.chineseWordsFreq, b.chineseWordsFreq)
+	mergeFreq(a.englishWordFreq, b.englishWordFreq)
+	mergeFreq(a.englishPhrasesFreq, b.englishPhrasesFreq)
+	mergeFreq(a.urlFreq, b.urlFreq)
+	mergeFreq(a.emailFreq, b.emailFreq)
+	mergeFreq(a.hashtagFreq, b.hashtagFreq)
+	mergeFreq(a.mentionFreq, b.mentionFreq)
+	mergeFreq(a.lineFreq, b.lineFreq)
+	mergeFreq(a.mixedFreq, b.mixedFreq)
+	mergeFreq(a.scriptFreq, b.scriptFreq)
+	mergeFreq(a.romanFreq, b.romanFreq)
+
+	// Word list ranges of b's files and sections shift by the words already held
+	englishOffset, chineseOffset := len(a.englishWordList), len(a.chineseWordsList)
+	for _, f := range b.files {
+		f.EnglishWords[0] += englishOffset
+		f.EnglishWords[1] += englishOffset
+		f.ChineseWords[0] += chineseOffset
+		f.ChineseWords[1] += chineseOffset
+		a.files = append(a.files, f)
+	}
+	for _, s := range b.sections {
+		s.EnglishHeader += englishOffset
+		s.EnglishStart += englishOffset
+		s.ChineseHeader += chineseOffset
+		s.ChineseStart += chineseOffset
+		a.sections = append(a.sections, s)
+	}
+
+	a.chineseCharList = append(a.chineseCharList, b.chineseCharList...)
+	a.chineseWordsList = append(a.chineseWordsList, b.chineseWordsList...)
+	a.englishWordList = append(a.englishWordList, b.englishWordList...)
+	keyOffset := len(a.englishKeyList)
+	for _, start := range b.englishKeyStart {
+		a.englishKeyStart = append(a.englishKeyStart, start+keyOffset)
+	}
+	a.englishKeyList = append(a.englishKeyList, b.englishKeyList...)
+	a.englishPhrasesList = append(a.englishPhrasesList, b.englishPhrasesList...)
+	a.urlList = append(a.urlList, b.urlList...)
+	a.emailList = append(a.emailList, b.emailList...)
+	a.hashtagList = append(a.hashtagList, b.hashtagList...)
+	a.mentionList = append(a.mentionList, b.mentionList...)
+
+	for term, forms := range b.wordForms {
+		if a.wordForms[term] == nil {
+			a.wordForms[term] = make(map[string]int)
+		}
+		mergeFreq(a.wordForms[term], forms)
+	}
+	for term, refs := range b.lineIndex {
+		a.lineIndex[term] = append(a.lineIndex[term], refs...)
+	}
+	for term, refs := range b.kwicLines {
+		if room := kwicLinesPerTerm - len(a.kwicLines[term]); room > 0 {
+			if len(refs) > room {
+				refs = refs[:room]
+			}
+			a.kwicLines[term] = append(a.kwicLines[term], refs...)
+		}
+	}
+
+	a.englishSentences += b.englishSentences
+	a.droppedTerms += b.droppedTerms
+}